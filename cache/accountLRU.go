@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+)
+
+// AccountLRUCache keeps up to size accounts, as last committed, across blocks.
+// Accounts are copied in and out, so that changes made while applying a block
+// never reach the cache before they are committed.
+type AccountLRUCache struct {
+	lock sync.Mutex
+
+	size     int
+	accounts map[common.Address]*list.Element
+	order    *list.List // Most recently used first
+
+	hits   uint64
+	misses uint64
+}
+
+func NewAccountLRUCache(size int) *AccountLRUCache {
+	return &AccountLRUCache{
+		size:     size,
+		accounts: make(map[common.Address]*list.Element),
+		order:    list.New(),
+	}
+}
+
+func copyAccount(a *models.Account) *models.Account {
+	c := *a
+	return &c
+}
+
+// Get returns a copy of the account cached for address, or nil
+func (c *AccountLRUCache) Get(address common.Address) *models.Account {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e, ok := c.accounts[address]
+	if !ok {
+		c.misses++
+		return nil
+	}
+	c.hits++
+	c.order.MoveToFront(e)
+	return copyAccount(e.Value.(*models.Account))
+}
+
+// Put caches a copy of account, evicting the least recently used account if full
+func (c *AccountLRUCache) Put(address common.Address, account *models.Account) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.accounts[address]; ok {
+		e.Value = copyAccount(account)
+		c.order.MoveToFront(e)
+		return
+	}
+	c.accounts[address] = c.order.PushFront(copyAccount(account))
+	if c.order.Len() > c.size {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.accounts, e.Value.(*models.Account).Address)
+	}
+}
+
+func (c *AccountLRUCache) Remove(address common.Address) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if e, ok := c.accounts[address]; ok {
+		c.order.Remove(e)
+		delete(c.accounts, address)
+	}
+}
+
+// Purge drops every cached account
+func (c *AccountLRUCache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.accounts = make(map[common.Address]*list.Element)
+	c.order.Init()
+}
+
+// PutAll caches the accounts of ac, once they have been committed
+func (c *AccountLRUCache) PutAll(ac AccountCache) {
+	for address, account := range ac {
+		c.Put(address, account)
+	}
+}
+
+// RemoveAll drops the accounts of ac, whose committed state is unknown
+func (c *AccountLRUCache) RemoveAll(ac AccountCache) {
+	for address := range ac {
+		c.Remove(address)
+	}
+}
+
+func (c *AccountLRUCache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.order.Len()
+}
+
+// HitRate returns the share of lookups found in the cache, since it was created
+func (c *AccountLRUCache) HitRate() float64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.hits+c.misses == 0 {
+		return 0
+	}
+	return float64(c.hits) / float64(c.hits+c.misses)
+}
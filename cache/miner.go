@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+)
+
+type MinerCache map[common.Address]*models.Miner
+
+func (m MinerCache) Get(address common.Address) *models.Miner {
+	return m[address]
+}
+
+func (m MinerCache) Put(address common.Address, value *models.Miner) {
+	m[address] = value
+}
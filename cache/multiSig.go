@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+)
+
+type MultiSigAddressCache map[common.Address]*models.MultiSigAddress
+
+func (m MultiSigAddressCache) Get(address common.Address) *models.MultiSigAddress {
+	return m[address]
+}
+
+func (m MultiSigAddressCache) Put(address common.Address, value *models.MultiSigAddress) {
+	m[address] = value
+}
+
+type MultiSigSpendCache map[common.Hash]*models.MultiSigSpend
+
+func (m MultiSigSpendCache) Get(sharedKey common.Hash) *models.MultiSigSpend {
+	return m[sharedKey]
+}
+
+func (m MultiSigSpendCache) Put(sharedKey common.Hash, value *models.MultiSigSpend) {
+	m[sharedKey] = value
+}
@@ -0,0 +1,31 @@
+package cache
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+)
+
+type OTSKeyUsageKey struct {
+	Address  common.Address
+	OTSIndex uint64
+}
+
+type OTSKeyUsageCache map[OTSKeyUsageKey]*models.OTSKeyUsage
+
+func (o OTSKeyUsageCache) Get(address common.Address, otsIndex uint64) *models.OTSKeyUsage {
+	return o[OTSKeyUsageKey{address, otsIndex}]
+}
+
+func (o OTSKeyUsageCache) Put(address common.Address, otsIndex uint64, value *models.OTSKeyUsage) {
+	o[OTSKeyUsageKey{address, otsIndex}] = value
+}
+
+type OTSKeyStatusCache map[common.Address]*models.OTSKeyStatus
+
+func (o OTSKeyStatusCache) Get(address common.Address) *models.OTSKeyStatus {
+	return o[address]
+}
+
+func (o OTSKeyStatusCache) Put(address common.Address, value *models.OTSKeyStatus) {
+	o[address] = value
+}
@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+)
+
+type ProposalCache map[common.Hash]*models.Proposal
+
+func (p ProposalCache) Get(sharedKey common.Hash) *models.Proposal {
+	return p[sharedKey]
+}
+
+func (p ProposalCache) Put(sharedKey common.Hash, value *models.Proposal) {
+	p[sharedKey] = value
+}
@@ -0,0 +1,15 @@
+package cache
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+)
+
+type StatsCache map[string]*models.Stats
+
+func (s StatsCache) Get(name string) *models.Stats {
+	return s[name]
+}
+
+func (s StatsCache) Put(name string, value *models.Stats) {
+	s[name] = value
+}
@@ -0,0 +1,16 @@
+package cache
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+)
+
+type TokenCache map[common.Hash]*models.Token
+
+func (t TokenCache) Get(txHash common.Hash) *models.Token {
+	return t[txHash]
+}
+
+func (t TokenCache) Put(txHash common.Hash, value *models.Token) {
+	t[txHash] = value
+}
@@ -0,0 +1,25 @@
+package cache
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+)
+
+type TokenBalanceChangeLogCache map[TokenHolderKey]*models.TokenBalanceChangeLog
+
+func (t TokenBalanceChangeLogCache) Get(tokenTxHash common.Hash, address common.Address) *models.TokenBalanceChangeLog {
+	return t[TokenHolderKey{tokenTxHash, address}]
+}
+
+func (t TokenBalanceChangeLogCache) Put(tokenTxHash common.Hash, address common.Address, value *models.TokenBalanceChangeLog) {
+	t[TokenHolderKey{tokenTxHash, address}] = value
+}
+
+func (t TokenBalanceChangeLogCache) Update(blockNumber int64, tokenTxHash common.Hash, address common.Address, deltaAmount int64) {
+	v := t.Get(tokenTxHash, address)
+	if v == nil {
+		v = models.NewTokenBalanceChangeLog(blockNumber, tokenTxHash, address)
+		t.Put(tokenTxHash, address, v)
+	}
+	v.UpdateDeltaAmount(deltaAmount)
+}
@@ -0,0 +1,21 @@
+package cache
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+)
+
+type TokenHolderKey struct {
+	TokenTxHash common.Hash
+	Address     common.Address
+}
+
+type TokenHolderCache map[TokenHolderKey]*models.TokenHolder
+
+func (t TokenHolderCache) Get(tokenTxHash common.Hash, address common.Address) *models.TokenHolder {
+	return t[TokenHolderKey{tokenTxHash, address}]
+}
+
+func (t TokenHolderCache) Put(tokenTxHash common.Hash, address common.Address, value *models.TokenHolder) {
+	t[TokenHolderKey{tokenTxHash, address}] = value
+}
@@ -15,7 +15,6 @@ import (
 	"github.com/theQRL/qrl-rich-list-indexer/db/models"
 	"github.com/theQRL/qrl-rich-list-indexer/generated"
 	"github.com/theQRL/qrl-rich-list-indexer/log"
-	"go.mongodb.org/mongo-driver/mongo"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
@@ -29,15 +28,15 @@ type QRLIndexer struct {
 
 	log log.LoggerInterface
 
-	config *config.Config
+	config       *config.Config
+	pollInterval time.Duration
 
-	m *db.MongoDBProcessor
+	m db.Storage
 
-	quit       chan struct{}
-	disconnect bool
+	quit chan struct{}
 }
 
-func ConnectServer(m *db.MongoDBProcessor) (*QRLIndexer, error) {
+func ConnectServer(m db.Storage) (*QRLIndexer, error) {
 	c := config.GetConfig()
 	qrlNodeConfig := c.GetQRLNodeConfig()
 	conn, err := grpc.Dial(fmt.Sprintf("%s:%d", qrlNodeConfig.IP, qrlNodeConfig.PublicAPIPort),
@@ -46,23 +45,30 @@ func ConnectServer(m *db.MongoDBProcessor) (*QRLIndexer, error) {
 		return nil, err
 	}
 
-	pac := generated.NewPublicAPIClient(conn)
-	m.SetPAC(pac)
-	nc := &QRLIndexer{
-		conn:   conn,
-		pac:    pac,
-		config: c,
-		log:    log.GetLogger(),
-		m:      m,
-		quit:   make(chan struct{}),
-	}
+	nc := NewQRLIndexer(generated.NewPublicAPIClient(conn), m)
+	nc.conn = conn
 	return nc, nil
 }
 
+// NewQRLIndexer creates a QRLIndexer syncing m from pac, which allows the sync
+// loop to run against any PublicAPIClient and storage backend
+func NewQRLIndexer(pac generated.PublicAPIClient, m db.Storage) *QRLIndexer {
+	c := config.GetConfig()
+	return &QRLIndexer{
+		pac:          pac,
+		config:       c,
+		pollInterval: time.Duration(c.PollIntervalSeconds) * time.Second,
+		log:          log.GetLogger(),
+		m:            m,
+		quit:         make(chan struct{}),
+	}
+}
+
 func (qi *QRLIndexer) Start() {
 	qi.lock.Lock()
 	defer qi.lock.Unlock()
 
+	qi.wg.Add(1)
 	go qi.run()
 }
 
@@ -76,27 +82,37 @@ func (qi *QRLIndexer) close() {
 
 	close(qi.quit)
 
-	qi.conn.Close()
+	if qi.conn != nil {
+		qi.conn.Close()
+	}
 }
 
 func (qi *QRLIndexer) Disconnect() {
 	qi.log.Info("Disconnecting...")
-	qi.disconnect = true
 	qi.close()
 	qi.wg.Wait()
 }
 
+// isDisconnected returns whether Disconnect has been called
+func (qi *QRLIndexer) isDisconnected() bool {
+	select {
+	case <-qi.quit:
+		return true
+	default:
+		return false
+	}
+}
+
 func (qi *QRLIndexer) run() (err error) {
-	qi.wg.Add(1)
 	defer qi.wg.Done()
 loop:
 	for {
 		select {
-		case <-time.After(10 * time.Second):
+		case <-time.After(qi.pollInterval):
 			height := uint64(common.BLOCKZERO)
 			b, err := qi.m.GetLastBlock()
 			// If last block not found, then request for genesis block and process it
-			if err == mongo.ErrNoDocuments {
+			if err == db.ErrNotFound {
 				block, err := qi.requestForBlockByNumber(height)
 				if err != nil {
 					qi.log.Error("[run] Error requestForBlockByNumber",
@@ -144,13 +160,62 @@ loop:
 				continue
 			}
 
-			for !qi.disconnect {
+			// The node height only decides whether to catch up in batches, so
+			// it is only requested again once a batch has been committed
+			nodeHeight, err := qi.requestForBlockHeight()
+			if err != nil {
+				qi.log.Error("[run] Error requestForBlockHeight",
+					"Error", err.Error())
+				return err
+			}
+
+			for !qi.isDisconnected() {
 				b, err = qi.m.GetLastBlock()
 				if err != nil {
 					qi.log.Error("[run] Error in GetLastBlock",
 						"Error", err.Error())
 					return err
 				}
+
+				// Blocks more than ReOrgLimit behind the tip of the node are
+				// committed in batches, closer to it every block is committed
+				// on its own
+				if qi.config.CatchUpBatchSize > 1 && nodeHeight > height+qi.config.ReOrgLimit {
+					count := nodeHeight - qi.config.ReOrgLimit - height
+					if count > qi.config.CatchUpBatchSize {
+						count = qi.config.CatchUpBatchSize
+					}
+					blocks, err := qi.requestForLinkedBlocks(b, count)
+					if err != nil {
+						qi.log.Error("[run] Error requestForLinkedBlocks while catching up",
+							"#", height+1,
+							"Error", err.Error())
+						return err
+					}
+					// The fork recovery will happen in next iteration
+					if len(blocks) == 0 {
+						break
+					}
+
+					err = qi.m.ProcessBlocks(blocks)
+					if err != nil {
+						qi.log.Error("[run] Failed to ProcessBlocks",
+							"From #", blocks[0].Header.BlockNumber,
+							"To #", blocks[len(blocks)-1].Header.BlockNumber,
+							"Error", err.Error())
+						return err
+					}
+					height = blocks[len(blocks)-1].Header.BlockNumber
+
+					nodeHeight, err = qi.requestForBlockHeight()
+					if err != nil {
+						qi.log.Error("[run] Error requestForBlockHeight",
+							"Error", err.Error())
+						return err
+					}
+					continue
+				}
+
 				block, err = qi.requestForBlockByNumber(height + 1)
 				if err != nil {
 					qi.log.Error("[run] Error requestForBlockByNumber while syncing",
@@ -212,6 +277,31 @@ func (qi *QRLIndexer) requestForBlockByNumber(blockNumber uint64) (*generated.Bl
 	return resp.Block, err
 }
 
+// requestForLinkedBlocks requests up to count blocks following b, stopping at
+// the first block which is missing or does not link to the one before it
+func (qi *QRLIndexer) requestForLinkedBlocks(b *models.Block, count uint64) ([]*generated.Block, error) {
+	var blocks []*generated.Block
+	prevHash := b.Hash[:]
+	for i := uint64(1); i <= count; i++ {
+		block, err := qi.requestForBlockByNumber(b.GetNumber() + i)
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			break
+		}
+		if !reflect.DeepEqual(prevHash, block.Header.HashHeaderPrev) {
+			qi.log.Info("fork found",
+				"#", block.Header.BlockNumber,
+				"prev hash", hex.EncodeToString(block.Header.HashHeaderPrev))
+			break
+		}
+		blocks = append(blocks, block)
+		prevHash = block.Header.HashHeader
+	}
+	return blocks, nil
+}
+
 func (qi *QRLIndexer) requestForBlockHeight() (uint64, error) {
 	resp, err := qi.pac.GetHeight(context.Background(),
 		&generated.GetHeightReq{})
@@ -0,0 +1,241 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/theQRL/qrl-rich-list-indexer/db"
+	"github.com/theQRL/qrl-rich-list-indexer/db/memory"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+	"github.com/theQRL/qrl-rich-list-indexer/rules"
+	"google.golang.org/grpc"
+)
+
+const testBlockReward = 100
+
+var (
+	minerA = []byte{0x0a}
+	minerB = []byte{0x0b}
+)
+
+// fakeNode serves the blocks of a chain which can be switched while syncing
+type fakeNode struct {
+	generated.PublicAPIClient
+
+	lock   sync.Mutex
+	blocks []*generated.Block
+}
+
+func (n *fakeNode) setBlocks(blocks []*generated.Block) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.blocks = blocks
+}
+
+func (n *fakeNode) GetHeight(ctx context.Context, in *generated.GetHeightReq,
+	opts ...grpc.CallOption) (*generated.GetHeightResp, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	return &generated.GetHeightResp{Height: uint64(len(n.blocks) - 1)}, nil
+}
+
+func (n *fakeNode) GetBlockByNumber(ctx context.Context, in *generated.GetBlockByNumberReq,
+	opts ...grpc.CallOption) (*generated.GetBlockByNumberResp, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if in.BlockNumber >= uint64(len(n.blocks)) {
+		return &generated.GetBlockByNumberResp{}, nil
+	}
+	return &generated.GetBlockByNumberResp{Block: n.blocks[in.BlockNumber]}, nil
+}
+
+// batchRecorder records the number of blocks committed by every call to the storage
+type batchRecorder struct {
+	db.Storage
+
+	lock    sync.Mutex
+	batches []int
+}
+
+func (r *batchRecorder) ProcessBlock(b *generated.Block) error {
+	r.record(1)
+	return r.Storage.ProcessBlock(b)
+}
+
+func (r *batchRecorder) ProcessBlocks(blocks []*generated.Block) error {
+	r.record(len(blocks))
+	return r.Storage.ProcessBlocks(blocks)
+}
+
+func (r *batchRecorder) record(count int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.batches = append(r.batches, count)
+}
+
+func (r *batchRecorder) getBatches() []int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return append([]int(nil), r.batches...)
+}
+
+// extendChain returns the blocks of chain below length, followed by blocks
+// mined by miner up to length. fork tells apart the hashes of the new blocks.
+func extendChain(chain []*generated.Block, length int, fork byte, miner []byte) []*generated.Block {
+	blocks := append([]*generated.Block(nil), chain[:min(len(chain), length)]...)
+	for number := len(blocks); number < length; number++ {
+		var prevHash []byte
+		if number > 0 {
+			prevHash = blocks[number-1].Header.HashHeader
+		}
+		blocks = append(blocks, &generated.Block{
+			Header: &generated.BlockHeader{
+				BlockNumber:      uint64(number),
+				HashHeader:       testHash(fork, number, 0x01),
+				HashHeaderPrev:   prevHash,
+				TimestampSeconds: 1600000000 + uint64(number)*60,
+			},
+			Transactions: []*generated.Transaction{{
+				TransactionHash: testHash(fork, number, 0x02),
+				TransactionType: &generated.Transaction_Coinbase{
+					Coinbase: &generated.Transaction_CoinBase{AddrTo: miner, Amount: testBlockReward},
+				},
+			}},
+		})
+	}
+	return blocks
+}
+
+// testHash returns a hash unique to the kind of object of a block of fork
+func testHash(fork byte, number int, kind byte) []byte {
+	hash := make([]byte, 32)
+	hash[0] = fork
+	hash[1] = byte(number >> 8)
+	hash[2] = byte(number)
+	hash[3] = kind
+	return hash
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func newTestIndexer(t *testing.T, node *fakeNode, m db.Storage) *QRLIndexer {
+	qi := NewQRLIndexer(node, m)
+	qi.pollInterval = time.Millisecond
+	qi.config.CatchUpBatchSize = 1
+	t.Cleanup(qi.Stop)
+	return qi
+}
+
+func newTestStorage(t *testing.T) *memory.MemoryProcessor {
+	engine, err := rules.NewEngine(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return memory.NewMemoryProcessor(engine)
+}
+
+// waitForLastBlock waits until the last block of m is the tip of blocks
+func waitForLastBlock(t *testing.T, m db.Storage, blocks []*generated.Block) {
+	tip := blocks[len(blocks)-1].Header
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		b, err := m.GetLastBlock()
+		if err == nil && b.GetNumber() == tip.BlockNumber && reflect.DeepEqual(b.Hash[:], tip.HashHeader) {
+			return
+		} else if err != nil && err != db.ErrNotFound {
+			t.Fatalf("GetLastBlock() error = %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("last block never reached #%d", tip.BlockNumber)
+}
+
+func checkBalance(t *testing.T, m db.Storage, miner []byte, want int64) {
+	a, err := m.GetAccountByAddress(misc.ToStringAddress(miner))
+	if err != nil {
+		t.Fatalf("GetAccountByAddress() error = %v", err)
+	}
+	if a.Balance != want {
+		t.Errorf("Balance of %s = %d, want %d", a.Address, a.Balance, want)
+	}
+}
+
+func TestQRLIndexerSync(t *testing.T) {
+	chain := extendChain(nil, 20, 0x01, minerA)
+	node := &fakeNode{blocks: chain}
+	m := newTestStorage(t)
+
+	newTestIndexer(t, node, m).Start()
+	waitForLastBlock(t, m, chain)
+	checkBalance(t, m, minerA, 20*testBlockReward)
+
+	// Blocks mined once synced are picked up on the next poll
+	chain = extendChain(chain, 25, 0x01, minerA)
+	node.setBlocks(chain)
+	waitForLastBlock(t, m, chain)
+	checkBalance(t, m, minerA, 25*testBlockReward)
+}
+
+func TestQRLIndexerForkRollback(t *testing.T) {
+	chain := extendChain(nil, 10, 0x01, minerA)
+	node := &fakeNode{blocks: chain}
+	m := newTestStorage(t)
+
+	newTestIndexer(t, node, m).Start()
+	waitForLastBlock(t, m, chain)
+
+	// The node switches to a longer fork diverging after block #5
+	fork := extendChain(chain, 6, 0x02, minerB)
+	fork = extendChain(fork, 13, 0x02, minerB)
+	node.setBlocks(fork)
+	waitForLastBlock(t, m, fork)
+	checkBalance(t, m, minerA, 6*testBlockReward)
+	checkBalance(t, m, minerB, 7*testBlockReward)
+
+	b, err := m.GetBlockByNumber(6)
+	if err != nil {
+		t.Fatalf("GetBlockByNumber(6) error = %v", err)
+	}
+	if !reflect.DeepEqual(b.Hash[:], fork[6].Header.HashHeader) {
+		t.Errorf("block #6 = %s, want the block of the fork", b.Hash.ToString())
+	}
+}
+
+func TestQRLIndexerBatchedCatchUp(t *testing.T) {
+	const (
+		reOrgLimit       = 5
+		catchUpBatchSize = 4
+	)
+	chain := extendChain(nil, 30, 0x01, minerA)
+	node := &fakeNode{blocks: chain}
+	m := &batchRecorder{Storage: newTestStorage(t)}
+
+	qi := newTestIndexer(t, node, m)
+	qi.config.ReOrgLimit = reOrgLimit
+	qi.config.CatchUpBatchSize = catchUpBatchSize
+	qi.Start()
+	waitForLastBlock(t, m, chain)
+	checkBalance(t, m, minerA, 30*testBlockReward)
+
+	// Genesis is committed on its own, then blocks are committed in batches
+	// until ReOrgLimit blocks behind the tip, and one by one after that
+	batches := m.getBatches()
+	want := []int{1, 4, 4, 4, 4, 4, 4, 1, 1, 1, 1, 1}
+	if !reflect.DeepEqual(batches, want) {
+		t.Errorf("batches = %v, want %v", batches, want)
+	}
+}
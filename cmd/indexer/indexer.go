@@ -1,17 +1,62 @@
 package main
 
 import (
+	"fmt"
 	"os"
 	"os/signal"
 
 	"github.com/theQRL/qrl-rich-list-indexer/client"
+	"github.com/theQRL/qrl-rich-list-indexer/config"
 	"github.com/theQRL/qrl-rich-list-indexer/db"
+	"github.com/theQRL/qrl-rich-list-indexer/db/memory"
+	"github.com/theQRL/qrl-rich-list-indexer/db/sqlite"
 	"github.com/theQRL/qrl-rich-list-indexer/log"
 )
 
+// createStorage creates the storage backend selected in the config
+func createStorage() (db.Storage, error) {
+	c := config.GetConfig()
+	switch c.StorageBackend {
+	case config.StorageBackendMongoDB:
+		// Create MongoDB Processor
+		m, err := db.CreateMongoDBProcessor()
+		if err != nil {
+			return nil, err
+		}
+
+		err = m.Migrate(false)
+		if err != nil {
+			return nil, err
+		}
+
+		// Revert the blocks affected by rule changes, so that they are reindexed
+		err = m.ReconcileRules()
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	case config.StorageBackendSQLite:
+		p, err := sqlite.CreateSQLiteProcessor()
+		if err != nil {
+			return nil, err
+		}
+
+		// Revert the blocks affected by rule changes, so that they are reindexed
+		err = p.ReconcileRules()
+		if err != nil {
+			return nil, err
+		}
+		return p, nil
+	case config.StorageBackendMemory:
+		// Nothing is persisted, so blocks are always indexed with the loaded rules
+		return memory.CreateMemoryProcessor()
+	default:
+		return nil, fmt.Errorf("unknown storage backend %s", c.StorageBackend)
+	}
+}
+
 func run() error {
-	// Create MongoDB Processor
-	m, err := db.CreateMongoDBProcessor()
+	m, err := createStorage()
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/theQRL/qrl-rich-list-indexer/db"
+	"github.com/theQRL/qrl-rich-list-indexer/log"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only list the pending migrations")
+	flag.Parse()
+
+	logger := log.GetLogger()
+	logger.Info("Starting Migration")
+
+	m, err := db.CreateMongoDBProcessor()
+	if err != nil {
+		logger.Error("Error while connecting to MongoDB",
+			"Error", err.Error())
+		return
+	}
+
+	err = m.Migrate(*dryRun)
+	if err != nil {
+		logger.Error("Error while migrating",
+			"Error", err.Error())
+		return
+	}
+
+	logger.Info("Migration finished")
+}
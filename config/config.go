@@ -1,12 +1,38 @@
 package config
 
+const (
+	StorageBackendMongoDB = "mongodb"
+	StorageBackendSQLite  = "sqlite"
+	StorageBackendMemory  = "memory" // Nothing is persisted, the index is rebuilt on every start
+)
+
 type Config struct {
 	qrlNodeConfig *QRLNodeConfig
 	mongoDBConfig *MongoDBConfig
+	sqliteConfig  *SQLiteConfig
+
+	StorageBackend string // Backend the indexed data is stored in, one of the StorageBackend constants
+
+	PollIntervalSeconds uint64 // Time waited before syncing with the node again, once synced
+
+	ReOrgLimit       uint64
+	CatchUpBatchSize uint64 // Blocks committed together while more than ReOrgLimit blocks behind the node, 1 commits every block on its own
+	ArchiveMode      bool   // Keep the blocks and change logs older than ReOrgLimit, only covers blocks indexed while enabled
+	RulesFilePath    string // JSON file with the address rules applied while indexing, empty to index without rules
+
+	AccountCacheSize int // Number of committed accounts kept in memory across blocks, 0 disables the cache
+
+	MinerShareWindows []int64 // Number of most recent blocks over which miner shares are calculated, mined blocks older than the widest window are pruned unless ArchiveMode
+
+	ProposalDefaultOptions []string
 
-	ReOrgLimit           uint64
-	BanStartBlockNumber  uint64
-	BannedQRLAddressList map[string]bool
+	OTSKeyWarningRemainingPercentage int64 // Addresses with less remaining OTS keys are flagged as near exhaustion
+
+	HolderThresholds []int64 // Balances in shor above which holders are counted in stats
+	TopHolderCounts  []int64 // Number of top holders whose total balance is tracked in stats
+
+	RichListSnapshotInterval int64 // A rich list snapshot is taken every RichListSnapshotInterval blocks and at the first block of each UTC day
+	RichListSnapshotSize     int64 // Number of top holders kept in a rich list snapshot
 }
 
 type QRLNodeConfig struct {
@@ -15,11 +41,25 @@ type QRLNodeConfig struct {
 }
 
 type MongoDBConfig struct {
+	URI string // Full connection string, when set Host, Port, Username, Password and the options below are ignored
+
 	DBName   string
 	Host     string
 	Port     uint16
 	Username string
 	Password string
+
+	AuthSource            string // Database the credentials are defined in, DBName if empty
+	ReplicaSet            string
+	TLS                   bool
+	TLSCAFile             string // PEM file with the certificate authorities trusted for the server certificate
+	TLSCertificateKeyFile string // PEM file with the client certificate and private key
+
+	ConnectTimeoutSeconds uint64 // Time allowed to connect and run the preflight check
+}
+
+type SQLiteConfig struct {
+	FilePath string
 }
 
 func GetConfig() *Config {
@@ -34,12 +74,41 @@ func GetConfig() *Config {
 			Port:     27017, // Default MongoDB port
 			Username: "",
 			Password: "",
+
+			ReplicaSet: "",
+			TLS:        false,
+
+			ConnectTimeoutSeconds: 60,
 		},
-		ReOrgLimit:          350,
-		BanStartBlockNumber: 2078800,
-		BannedQRLAddressList: map[string]bool{
-			"Q010600fcd0db869d2e1b17b452bdf9848f6fe8c74ee5b8f935408cc558c601fb69eb553fa916a1": true,
+		sqliteConfig: &SQLiteConfig{
+			FilePath: "QRLRichListIndexer.db",
 		},
+		StorageBackend: StorageBackendMongoDB,
+
+		PollIntervalSeconds: 10,
+
+		ReOrgLimit:       350,
+		CatchUpBatchSize: 100,
+		ArchiveMode:      false,
+		RulesFilePath:    "rules.json",
+
+		AccountCacheSize: 100000,
+
+		MinerShareWindows:      []int64{1000, 10000},
+		ProposalDefaultOptions: []string{"YES", "NO", "ABSTAIN"},
+
+		OTSKeyWarningRemainingPercentage: 5,
+
+		HolderThresholds: []int64{
+			1000000000,       // 1 Quanta
+			1000000000000,    // 1,000 Quanta
+			100000000000000,  // 100,000 Quanta
+			1000000000000000, // 1,000,000 Quanta
+		},
+		TopHolderCounts: []int64{10, 100, 1000},
+
+		RichListSnapshotInterval: 10000,
+		RichListSnapshotSize:     1000,
 	}
 	return c
 }
@@ -51,3 +120,7 @@ func (c *Config) GetQRLNodeConfig() *QRLNodeConfig {
 func (c *Config) GetMongoDBConfig() *MongoDBConfig {
 	return c.mongoDBConfig
 }
+
+func (c *Config) GetSQLiteConfig() *SQLiteConfig {
+	return c.sqliteConfig
+}
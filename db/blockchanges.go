@@ -0,0 +1,418 @@
+package db
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/cache"
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/log"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+	"github.com/theQRL/qrl-rich-list-indexer/rules"
+	"github.com/theQRL/qrl-rich-list-indexer/xmss"
+)
+
+// BlockState is the indexed state read by ComputeBlockChanges and
+// ComputeRevertChanges. Reads of missing multisig data must return ErrNotFound.
+type BlockState interface {
+	GetAccountByAddress(address common.Address) (*models.Account, error)
+	GetBalanceChangeLogsByBlockNumber(blockNumber int64) ([]*models.BalanceChangeLog, error)
+
+	GetMultiSigAddress(address common.Address) (*models.MultiSigAddress, error)
+	GetMultiSigAddressesByBlockNumber(blockNumber int64) ([]*models.MultiSigAddress, error)
+	GetMultiSigSpend(sharedKey common.Hash) (*models.MultiSigSpend, error)
+	GetMultiSigSpendsByExecutedBlockNumber(blockNumber int64) ([]*models.MultiSigSpend, error)
+	GetMultiSigVotesByBlockNumber(blockNumber int64) ([]*models.MultiSigVote, error)
+}
+
+// BlockChanges holds the state a block changes in the accounts, blocks and
+// balanceChangeLogs semantics shared by every storage backend. Multisig state
+// is included as executed multisig spends move balances.
+type BlockChanges struct {
+	Block             *models.Block
+	Accounts          cache.AccountCache
+	BalanceChangeLogs cache.BalanceChangeLogCache
+	RuleAudits        []*models.RuleAudit
+
+	MultiSigAddresses []*models.MultiSigAddress
+	MultiSigSpends    cache.MultiSigSpendCache
+	MultiSigVotes     []*models.MultiSigVote
+}
+
+func newBlockChanges(b *models.Block) *BlockChanges {
+	return &BlockChanges{
+		Block:             b,
+		Accounts:          make(cache.AccountCache),
+		BalanceChangeLogs: make(cache.BalanceChangeLogCache),
+		MultiSigSpends:    make(cache.MultiSigSpendCache),
+	}
+}
+
+func (c *BlockChanges) getAccount(state BlockState, address common.Address) (*models.Account, error) {
+	a := c.Accounts.Get(address)
+	if a != nil {
+		return a, nil
+	}
+	a, err := state.GetAccountByAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	c.Accounts.Put(address, a)
+	return a, nil
+}
+
+func (c *BlockChanges) getMultiSigSpend(state BlockState, sharedKey common.Hash) (*models.MultiSigSpend, error) {
+	s := c.MultiSigSpends.Get(sharedKey)
+	if s != nil {
+		return s, nil
+	}
+	s, err := state.GetMultiSigSpend(sharedKey)
+	if err != nil {
+		return nil, err
+	}
+	c.MultiSigSpends.Put(sharedKey, s)
+	return s, nil
+}
+
+func (c *BlockChanges) getMultiSigAddress(state BlockState, address common.Address) (*models.MultiSigAddress, error) {
+	for _, multiSigAddress := range c.MultiSigAddresses {
+		if multiSigAddress.Address == address {
+			return multiSigAddress, nil
+		}
+	}
+	return state.GetMultiSigAddress(address)
+}
+
+func getOrCreateBalanceChangeLog(blockNumber int64, a *models.Account,
+	balanceChangeLogCache cache.BalanceChangeLogCache) *models.BalanceChangeLog {
+	balanceChangeLog := balanceChangeLogCache.Get(a.Address)
+	if balanceChangeLog == nil {
+		balanceChangeLog = models.NewBalanceChangeLog(blockNumber, a.Address)
+		// Keep the last activity before this block, so that it can be restored on revert
+		balanceChangeLog.PrevLastActiveBlockNumber = a.LastActiveBlockNumber
+		balanceChangeLog.PrevLastActiveTimestamp = a.LastActiveTimestamp
+		balanceChangeLogCache.Put(a.Address, balanceChangeLog)
+	}
+	return balanceChangeLog
+}
+
+func (c *BlockChanges) updateAccountAndLog(state BlockState, address common.Address, amount int64,
+	activity *models.AccountActivity) error {
+	a, err := c.getAccount(state, address)
+	if err != nil {
+		return err
+	}
+	balanceChangeLog := getOrCreateBalanceChangeLog(c.Block.Number, a, c.BalanceChangeLogs)
+
+	a.UpdateBalance(amount)
+	balanceChangeLog.UpdateDeltaAmount(amount)
+	if activity != nil {
+		a.UpdateActivity(activity)
+		balanceChangeLog.UpdateActivity(activity)
+	}
+
+	return nil
+}
+
+// skipFrozenTransfer tells whether the transfer of amounts from addrFrom to
+// addrsTo involves an address frozen at the block, and records the balance
+// change the transfer would have made to every frozen address in the audit.
+// A frozen transfer is skipped as a whole, so that the supply is conserved.
+func (c *BlockChanges) skipFrozenTransfer(engine *rules.Engine, addrFrom common.Address,
+	addrsTo []common.Address, amounts []int64) bool {
+	deltas := map[common.Address]int64{addrFrom: 0}
+	participants := []common.Address{addrFrom}
+	for i, address := range addrsTo {
+		if _, ok := deltas[address]; !ok {
+			participants = append(participants, address)
+		}
+		deltas[address] += amounts[i]
+		deltas[addrFrom] -= amounts[i]
+	}
+
+	frozen := false
+	for _, address := range participants {
+		rule := engine.ActiveRule(address, rules.ActionFreeze, c.Block.GetNumber())
+		if rule == nil {
+			continue
+		}
+		frozen = true
+		c.RuleAudits = append(c.RuleAudits, models.NewRuleAudit(c.Block.Number, rule, deltas[address]))
+	}
+	return frozen
+}
+
+func (c *BlockChanges) applyMultiSigVote(state BlockState, engine *rules.Engine, addrFrom common.Address,
+	protoTX *generated.Transaction) error {
+	blockNumber := c.Block.Number
+	multiSigVoteTX := protoTX.GetMultiSigVote()
+	sharedKey := misc.ToSizedHash(multiSigVoteTX.SharedKey)
+	multiSigSpend, err := c.getMultiSigSpend(state, sharedKey)
+	if err == ErrNotFound {
+		log.GetLogger().Warn("[ComputeBlockChanges] MultiSigSpend not found for MultiSigVote",
+			"sharedKey", sharedKey.ToString())
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	weight, applied := multiSigSpend.ApplyVote(blockNumber, addrFrom, multiSigVoteTX.Unvote)
+	if !applied {
+		return nil
+	}
+	c.MultiSigVotes = append(c.MultiSigVotes, models.NewMultiSigVote(blockNumber, protoTX.TransactionHash,
+		sharedKey, addrFrom, multiSigVoteTX.Unvote, weight))
+
+	if !multiSigSpend.IsExecutable(blockNumber) {
+		return nil
+	}
+	multiSigAccount, err := c.getAccount(state, multiSigSpend.MultiSigAddress)
+	if err != nil {
+		return err
+	}
+	totalAmountSpentByMultiSig := multiSigSpend.TotalAmount()
+	// Spend remains unexecuted if multi sig address doesn't have sufficient balance
+	if multiSigAccount.Balance < totalAmountSpentByMultiSig {
+		return nil
+	}
+	// The spend is executed on chain, even though no amount moves while a participant is frozen
+	if c.skipFrozenTransfer(engine, multiSigSpend.MultiSigAddress, multiSigSpend.AddrsTo, multiSigSpend.Amounts) {
+		multiSigSpend.SetExecuted(blockNumber)
+		return nil
+	}
+	recipients := make(map[common.Address]bool)
+	for i, address := range multiSigSpend.AddrsTo {
+		activity := models.NewIncomingActivity(multiSigSpend.Amounts[i])
+		if recipients[address] {
+			activity.IncomingTxCount = 0
+		}
+		recipients[address] = true
+
+		err := c.updateAccountAndLog(state, address, multiSigSpend.Amounts[i], activity)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.updateAccountAndLog(state, multiSigSpend.MultiSigAddress, totalAmountSpentByMultiSig*-1,
+		models.NewOutgoingActivity(totalAmountSpentByMultiSig, 0))
+	if err != nil {
+		return err
+	}
+	multiSigSpend.SetExecuted(blockNumber)
+
+	return nil
+}
+
+func (c *BlockChanges) applyRules(state BlockState, engine *rules.Engine) error {
+	for _, rule := range engine.ActiveRules(rules.ActionZero, c.Block.GetNumber()) {
+		a, err := c.getAccount(state, rule.Address)
+		if err != nil {
+			return err
+		}
+		if a.Balance == 0 {
+			continue
+		}
+		amount := a.Balance * -1
+		err = c.updateAccountAndLog(state, rule.Address, amount, nil)
+		if err != nil {
+			return err
+		}
+		c.RuleAudits = append(c.RuleAudits, models.NewRuleAudit(c.Block.Number, rule, amount))
+	}
+
+	return nil
+}
+
+// getAddrFrom returns the address paying for protoTX, which is empty for coinbase transactions
+func getAddrFrom(protoTX *generated.Transaction) common.Address {
+	if _, ok := protoTX.TransactionType.(*generated.Transaction_Coinbase); ok {
+		return ""
+	}
+	if protoTX.MasterAddr != nil {
+		return misc.ToStringAddress(protoTX.MasterAddr)
+	}
+	return xmss.GetXMSSAddressFromPK(protoTX.PublicKey)
+}
+
+// ComputeBlockChanges computes the balance, multisig and rule changes of
+// applying b on top of state. Every storage backend applies blocks through it,
+// and records whatever else it indexes from b on top of the changes.
+func ComputeBlockChanges(b *generated.Block, state BlockState, engine *rules.Engine) (*BlockChanges, error) {
+	c := newBlockChanges(models.NewBlockFromPBData(b))
+	blockNumber := c.Block.Number
+
+	// Genesis balances are only carried by block zero and are not backed by any transaction
+	if blockNumber == common.BLOCKZERO {
+		for _, genesisBalance := range b.GenesisBalance {
+			amount := int64(genesisBalance.Balance)
+			err := c.updateAccountAndLog(state, misc.ToStringAddress(genesisBalance.Address), amount,
+				&models.AccountActivity{TotalReceived: amount})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, protoTX := range b.Transactions {
+		addrFrom := getAddrFrom(protoTX)
+		totalAmountSpent := int64(protoTX.Fee)
+
+		switch protoTX.TransactionType.(type) {
+		case *generated.Transaction_Coinbase:
+			coinBaseTX := protoTX.GetCoinbase()
+			amount := int64(coinBaseTX.Amount)
+			err := c.updateAccountAndLog(state, misc.ToStringAddress(coinBaseTX.AddrTo), amount,
+				models.NewIncomingActivity(amount))
+			if err != nil {
+				return nil, err
+			}
+		case *generated.Transaction_Transfer_:
+			transferTX := protoTX.GetTransfer()
+			addrsTo := make([]common.Address, len(transferTX.AddrsTo))
+			amounts := make([]int64, len(transferTX.Amounts))
+			for i, addr := range transferTX.AddrsTo {
+				addrsTo[i] = misc.ToStringAddress(addr)
+				amounts[i] = int64(transferTX.Amounts[i])
+			}
+			// The fee of a frozen transfer is still paid, as the transaction is on chain
+			if c.skipFrozenTransfer(engine, addrFrom, addrsTo, amounts) {
+				break
+			}
+			recipients := make(map[common.Address]bool)
+			for i, address := range addrsTo {
+				amount := amounts[i]
+				totalAmountSpent += amount
+
+				activity := models.NewIncomingActivity(amount)
+				// Multiple outputs to the same address are counted as a single incoming transaction
+				if recipients[address] {
+					activity.IncomingTxCount = 0
+				}
+				recipients[address] = true
+
+				err := c.updateAccountAndLog(state, address, amount, activity)
+				if err != nil {
+					return nil, err
+				}
+			}
+		case *generated.Transaction_LatticePK, *generated.Transaction_Message_, *generated.Transaction_Token_,
+			*generated.Transaction_TransferToken_, *generated.Transaction_Slave_,
+			*generated.Transaction_ProposalCreate_, *generated.Transaction_ProposalVote_:
+		case *generated.Transaction_MultiSigCreate_:
+			multiSigAddress := models.NewMultiSigAddressFromPBData(blockNumber, addrFrom, protoTX)
+			c.MultiSigAddresses = append(c.MultiSigAddresses, multiSigAddress)
+
+			a, err := c.getAccount(state, multiSigAddress.Address)
+			if err != nil {
+				return nil, err
+			}
+			a.IsMultiSig = true
+		case *generated.Transaction_MultiSigSpend_:
+			multiSigSpendTX := protoTX.GetMultiSigSpend()
+			multiSigAddress, err := c.getMultiSigAddress(state, misc.ToStringAddress(multiSigSpendTX.MultiSigAddress))
+			if err == ErrNotFound {
+				log.GetLogger().Warn("[ComputeBlockChanges] MultiSigAddress not found for MultiSigSpend",
+					"multiSigAddress", misc.ToStringAddress(multiSigSpendTX.MultiSigAddress))
+				break
+			} else if err != nil {
+				return nil, err
+			}
+			multiSigSpend := models.NewMultiSigSpendFromPBData(blockNumber, addrFrom, multiSigAddress, protoTX)
+			c.MultiSigSpends.Put(multiSigSpend.SharedKey, multiSigSpend)
+		case *generated.Transaction_MultiSigVote_:
+			// The vote is applied to the vote state maintained by the indexer, and
+			// the spend is executed at the block in which its threshold is reached
+			err := c.applyMultiSigVote(state, engine, addrFrom, protoTX)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+
+		if len(addrFrom) != 0 {
+			fee := int64(protoTX.Fee)
+			err := c.updateAccountAndLog(state, addrFrom, totalAmountSpent*-1,
+				models.NewOutgoingActivity(totalAmountSpent-fee, fee))
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err := c.applyRules(state, engine)
+	if err != nil {
+		return nil, err
+	}
+
+	for addr, balanceChangeLog := range c.BalanceChangeLogs {
+		a := c.Accounts.Get(addr)
+		a.Touch(blockNumber, b.Header.TimestampSeconds)
+		balanceChangeLog.BalanceAfter = a.Balance
+	}
+
+	return c, nil
+}
+
+// ComputeRevertChanges computes the changes of reverting b, the last block in
+// state. Multisig addresses, spends and votes created by b are not part of
+// the changes and have to be deleted by the backend.
+func ComputeRevertChanges(b *models.Block, state BlockState) (*BlockChanges, error) {
+	c := newBlockChanges(b)
+
+	balanceChangeLogs, err := state.GetBalanceChangeLogsByBlockNumber(b.Number)
+	if err != nil {
+		return nil, err
+	}
+	for _, balanceChangeLog := range balanceChangeLogs {
+		err := c.updateAccountAndLog(state, balanceChangeLog.Address, balanceChangeLog.DeltaAmount*-1, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.Accounts.Get(balanceChangeLog.Address).RevertActivity(balanceChangeLog)
+	}
+
+	multiSigAddresses, err := state.GetMultiSigAddressesByBlockNumber(b.Number)
+	if err != nil {
+		return nil, err
+	}
+	for _, multiSigAddress := range multiSigAddresses {
+		a, err := c.getAccount(state, multiSigAddress.Address)
+		if err != nil {
+			return nil, err
+		}
+		a.IsMultiSig = false
+	}
+
+	multiSigVotes, err := state.GetMultiSigVotesByBlockNumber(b.Number)
+	if err != nil {
+		return nil, err
+	}
+	for _, multiSigVote := range multiSigVotes {
+		multiSigSpend, err := c.getMultiSigSpend(state, multiSigVote.SharedKey)
+		if err != nil {
+			return nil, err
+		}
+		multiSigSpend.RevertVote(multiSigVote.Voter, multiSigVote.Unvote, multiSigVote.Weight)
+	}
+
+	executedMultiSigSpends, err := state.GetMultiSigSpendsByExecutedBlockNumber(b.Number)
+	if err != nil {
+		return nil, err
+	}
+	for _, executedMultiSigSpend := range executedMultiSigSpends {
+		multiSigSpend, err := c.getMultiSigSpend(state, executedMultiSigSpend.SharedKey)
+		if err != nil {
+			return nil, err
+		}
+		multiSigSpend.ResetExecuted()
+	}
+
+	for sharedKey, multiSigSpend := range c.MultiSigSpends {
+		if multiSigSpend.BlockNumber == b.Number {
+			delete(c.MultiSigSpends, sharedKey)
+		}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,506 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+	"github.com/theQRL/qrl-rich-list-indexer/rules"
+)
+
+var (
+	senderAddress    = []byte{0x01, 0x01}
+	recipientAddress = []byte{0x02, 0x02}
+	otherAddress     = []byte{0x03, 0x03}
+)
+
+// fakeBlockState is an in memory BlockState, which returns copies so that
+// the changes are only seen once committed
+type fakeBlockState struct {
+	accounts          map[common.Address]models.Account
+	balanceChangeLogs map[int64][]*models.BalanceChangeLog
+	multiSigAddresses map[common.Address]*models.MultiSigAddress
+	multiSigSpends    map[common.Hash]models.MultiSigSpend
+	multiSigVotes     map[int64][]*models.MultiSigVote
+}
+
+func newFakeBlockState() *fakeBlockState {
+	return &fakeBlockState{
+		accounts:          make(map[common.Address]models.Account),
+		balanceChangeLogs: make(map[int64][]*models.BalanceChangeLog),
+		multiSigAddresses: make(map[common.Address]*models.MultiSigAddress),
+		multiSigSpends:    make(map[common.Hash]models.MultiSigSpend),
+		multiSigVotes:     make(map[int64][]*models.MultiSigVote),
+	}
+}
+
+func (s *fakeBlockState) GetAccountByAddress(address common.Address) (*models.Account, error) {
+	a, ok := s.accounts[address]
+	if !ok {
+		return models.NewAccount(address), nil
+	}
+	return &a, nil
+}
+
+func (s *fakeBlockState) GetBalanceChangeLogsByBlockNumber(blockNumber int64) ([]*models.BalanceChangeLog, error) {
+	return s.balanceChangeLogs[blockNumber], nil
+}
+
+func (s *fakeBlockState) GetMultiSigAddress(address common.Address) (*models.MultiSigAddress, error) {
+	multiSigAddress, ok := s.multiSigAddresses[address]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return multiSigAddress, nil
+}
+
+func (s *fakeBlockState) GetMultiSigAddressesByBlockNumber(blockNumber int64) ([]*models.MultiSigAddress, error) {
+	var multiSigAddresses []*models.MultiSigAddress
+	for _, multiSigAddress := range s.multiSigAddresses {
+		if multiSigAddress.BlockNumber == blockNumber {
+			multiSigAddresses = append(multiSigAddresses, multiSigAddress)
+		}
+	}
+	return multiSigAddresses, nil
+}
+
+func (s *fakeBlockState) GetMultiSigSpend(sharedKey common.Hash) (*models.MultiSigSpend, error) {
+	multiSigSpend, ok := s.multiSigSpends[sharedKey]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	multiSigSpend.Unvotes = append([]bool(nil), multiSigSpend.Unvotes...)
+	return &multiSigSpend, nil
+}
+
+func (s *fakeBlockState) GetMultiSigSpendsByExecutedBlockNumber(blockNumber int64) ([]*models.MultiSigSpend, error) {
+	var multiSigSpends []*models.MultiSigSpend
+	for sharedKey, multiSigSpend := range s.multiSigSpends {
+		if multiSigSpend.Executed && multiSigSpend.ExecutedBlockNumber == blockNumber {
+			m, _ := s.GetMultiSigSpend(sharedKey)
+			multiSigSpends = append(multiSigSpends, m)
+		}
+	}
+	return multiSigSpends, nil
+}
+
+func (s *fakeBlockState) GetMultiSigVotesByBlockNumber(blockNumber int64) ([]*models.MultiSigVote, error) {
+	return s.multiSigVotes[blockNumber], nil
+}
+
+// apply commits the changes of applying a block the way a storage backend does
+func (s *fakeBlockState) apply(c *BlockChanges) {
+	for address, a := range c.Accounts {
+		s.accounts[address] = *a
+	}
+	var balanceChangeLogs []*models.BalanceChangeLog
+	for _, balanceChangeLog := range c.BalanceChangeLogs {
+		balanceChangeLogs = append(balanceChangeLogs, balanceChangeLog)
+	}
+	s.balanceChangeLogs[c.Block.Number] = balanceChangeLogs
+	for _, multiSigAddress := range c.MultiSigAddresses {
+		s.multiSigAddresses[multiSigAddress.Address] = multiSigAddress
+	}
+	for sharedKey, multiSigSpend := range c.MultiSigSpends {
+		s.multiSigSpends[sharedKey] = *multiSigSpend
+	}
+	s.multiSigVotes[c.Block.Number] = c.MultiSigVotes
+}
+
+// revert commits the changes of reverting a block the way a storage backend does
+func (s *fakeBlockState) revert(c *BlockChanges) {
+	for address, a := range c.Accounts {
+		s.accounts[address] = *a
+	}
+	delete(s.balanceChangeLogs, c.Block.Number)
+	for address, multiSigAddress := range s.multiSigAddresses {
+		if multiSigAddress.BlockNumber == c.Block.Number {
+			delete(s.multiSigAddresses, address)
+		}
+	}
+	for sharedKey, multiSigSpend := range s.multiSigSpends {
+		if multiSigSpend.BlockNumber == c.Block.Number {
+			delete(s.multiSigSpends, sharedKey)
+		}
+	}
+	for sharedKey, multiSigSpend := range c.MultiSigSpends {
+		s.multiSigSpends[sharedKey] = *multiSigSpend
+	}
+	delete(s.multiSigVotes, c.Block.Number)
+}
+
+func (s *fakeBlockState) balance(address []byte) int64 {
+	return s.accounts[misc.ToStringAddress(address)].Balance
+}
+
+func newTestEngine(t *testing.T, r ...*rules.Rule) *rules.Engine {
+	engine, err := rules.NewEngine(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return engine
+}
+
+func newTestBlock(number uint64, transactions ...*generated.Transaction) *generated.Block {
+	return &generated.Block{
+		Header: &generated.BlockHeader{
+			BlockNumber:      number,
+			HashHeader:       []byte{byte(number), 0x01},
+			HashHeaderPrev:   []byte{byte(number - 1), 0x01},
+			TimestampSeconds: 1600000000 + number*60,
+		},
+		Transactions: transactions,
+	}
+}
+
+func newCoinbaseTX(addrTo []byte, amount uint64) *generated.Transaction {
+	return &generated.Transaction{
+		TransactionHash: []byte{0x0c, byte(amount)},
+		TransactionType: &generated.Transaction_Coinbase{
+			Coinbase: &generated.Transaction_CoinBase{AddrTo: addrTo, Amount: amount},
+		},
+	}
+}
+
+func newTransferTX(addrFrom []byte, fee uint64, addrsTo [][]byte, amounts []uint64) *generated.Transaction {
+	return &generated.Transaction{
+		MasterAddr:      addrFrom,
+		Fee:             fee,
+		TransactionHash: []byte{0x0d, byte(len(addrsTo)), byte(fee)},
+		TransactionType: &generated.Transaction_Transfer_{
+			Transfer: &generated.Transaction_Transfer{AddrsTo: addrsTo, Amounts: amounts},
+		},
+	}
+}
+
+// applyTestBlock computes and commits the changes of b
+func applyTestBlock(t *testing.T, state *fakeBlockState, b *generated.Block, engine *rules.Engine) *BlockChanges {
+	c, err := ComputeBlockChanges(b, state, engine)
+	if err != nil {
+		t.Fatalf("ComputeBlockChanges() error = %v", err)
+	}
+	state.apply(c)
+	return c
+}
+
+func TestComputeBlockChangesFreeze(t *testing.T) {
+	frozen := func(address []byte) *rules.Rule {
+		return &rules.Rule{
+			Address:     misc.ToStringAddress(address),
+			Action:      rules.ActionFreeze,
+			StartHeight: 1,
+			Reason:      "test",
+		}
+	}
+	transfer := newTransferTX(senderAddress, 1,
+		[][]byte{recipientAddress, otherAddress}, []uint64{100, 50})
+
+	tests := []struct {
+		name          string
+		rules         []*rules.Rule
+		wantBalances  map[string]int64
+		wantAuditFrom []byte
+		wantAudit     int64
+	}{
+		{
+			name: "no rule",
+			wantBalances: map[string]int64{
+				"sender": 849, "recipient": 100, "other": 50,
+			},
+		},
+		{
+			name:  "frozen recipient",
+			rules: []*rules.Rule{frozen(recipientAddress)},
+			wantBalances: map[string]int64{
+				"sender": 999, "recipient": 0, "other": 0,
+			},
+			wantAuditFrom: recipientAddress,
+			wantAudit:     100,
+		},
+		{
+			name:  "frozen sender",
+			rules: []*rules.Rule{frozen(senderAddress)},
+			wantBalances: map[string]int64{
+				"sender": 999, "recipient": 0, "other": 0,
+			},
+			wantAuditFrom: senderAddress,
+			wantAudit:     -150,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestEngine(t, tt.rules...)
+			state := newFakeBlockState()
+			applyTestBlock(t, state, newTestBlock(0, newCoinbaseTX(senderAddress, 1000)), engine)
+			c := applyTestBlock(t, state, newTestBlock(1, transfer), engine)
+
+			addresses := map[string][]byte{
+				"sender": senderAddress, "recipient": recipientAddress, "other": otherAddress,
+			}
+			total := int64(0)
+			for name, address := range addresses {
+				total += state.balance(address)
+				if balance := state.balance(address); balance != tt.wantBalances[name] {
+					t.Errorf("balance of %s = %d, want %d", name, balance, tt.wantBalances[name])
+				}
+			}
+			// Only the fee leaves the indexed supply, as it is paid to the miner by the coinbase
+			if total != 999 {
+				t.Errorf("total balance = %d, want 999", total)
+			}
+
+			if tt.wantAuditFrom == nil {
+				if len(c.RuleAudits) != 0 {
+					t.Errorf("RuleAudits = %v, want none", c.RuleAudits)
+				}
+				return
+			}
+			if len(c.RuleAudits) != 1 {
+				t.Fatalf("len(RuleAudits) = %d, want 1", len(c.RuleAudits))
+			}
+			ruleAudit := c.RuleAudits[0]
+			if ruleAudit.Address != misc.ToStringAddress(tt.wantAuditFrom) || ruleAudit.Amount != tt.wantAudit {
+				t.Errorf("RuleAudit = (%s, %d), want (%s, %d)", ruleAudit.Address, ruleAudit.Amount,
+					misc.ToStringAddress(tt.wantAuditFrom), tt.wantAudit)
+			}
+		})
+	}
+}
+
+func TestComputeBlockChangesZero(t *testing.T) {
+	engine := newTestEngine(t, &rules.Rule{
+		Address:     misc.ToStringAddress(recipientAddress),
+		Action:      rules.ActionZero,
+		StartHeight: 1,
+	})
+	state := newFakeBlockState()
+	applyTestBlock(t, state, newTestBlock(0, newCoinbaseTX(recipientAddress, 1000)), engine)
+	if balance := state.balance(recipientAddress); balance != 1000 {
+		t.Fatalf("balance before the rule = %d, want 1000", balance)
+	}
+
+	c := applyTestBlock(t, state, newTestBlock(1, newCoinbaseTX(recipientAddress, 100)), engine)
+	if balance := state.balance(recipientAddress); balance != 0 {
+		t.Errorf("balance with a zero rule = %d, want 0", balance)
+	}
+	if len(c.RuleAudits) != 1 || c.RuleAudits[0].Amount != -1100 {
+		t.Errorf("RuleAudits = %v, want a single audit of -1100", c.RuleAudits)
+	}
+}
+
+func newMultiSigCreateTX(txHash []byte, addrFrom []byte, signatories [][]byte, weights []uint32,
+	threshold uint32) *generated.Transaction {
+	return &generated.Transaction{
+		MasterAddr:      addrFrom,
+		TransactionHash: txHash,
+		TransactionType: &generated.Transaction_MultiSigCreate_{
+			MultiSigCreate: &generated.Transaction_MultiSigCreate{
+				Signatories: signatories,
+				Weights:     weights,
+				Threshold:   threshold,
+			},
+		},
+	}
+}
+
+func newMultiSigSpendTX(txHash []byte, addrFrom []byte, multiSigAddress common.Address, addrsTo [][]byte,
+	amounts []uint64, expiryBlockNumber uint64) *generated.Transaction {
+	byteAddress, _ := misc.ToByteAddress(multiSigAddress)
+	return &generated.Transaction{
+		MasterAddr:      addrFrom,
+		TransactionHash: txHash,
+		TransactionType: &generated.Transaction_MultiSigSpend_{
+			MultiSigSpend: &generated.Transaction_MultiSigSpend{
+				MultiSigAddress:   byteAddress[:],
+				AddrsTo:           addrsTo,
+				Amounts:           amounts,
+				ExpiryBlockNumber: expiryBlockNumber,
+			},
+		},
+	}
+}
+
+func newMultiSigVoteTX(txHash []byte, addrFrom []byte, sharedKey []byte, unvote bool) *generated.Transaction {
+	return &generated.Transaction{
+		MasterAddr:      addrFrom,
+		TransactionHash: txHash,
+		TransactionType: &generated.Transaction_MultiSigVote_{
+			MultiSigVote: &generated.Transaction_MultiSigVote{SharedKey: sharedKey, Unvote: unvote},
+		},
+	}
+}
+
+// fakeBlockStateSnapshot holds what reverting a block has to restore
+type fakeBlockStateSnapshot struct {
+	balances          map[common.Address]int64
+	multiSig          map[common.Address]bool
+	multiSigAddresses int
+	multiSigSpends    map[common.Hash]models.MultiSigSpend
+}
+
+func (s *fakeBlockState) snapshot() *fakeBlockStateSnapshot {
+	snapshot := &fakeBlockStateSnapshot{
+		balances:          make(map[common.Address]int64),
+		multiSig:          make(map[common.Address]bool),
+		multiSigAddresses: len(s.multiSigAddresses),
+		multiSigSpends:    make(map[common.Hash]models.MultiSigSpend),
+	}
+	for address, a := range s.accounts {
+		snapshot.balances[address] = a.Balance
+		snapshot.multiSig[address] = a.IsMultiSig
+	}
+	for sharedKey := range s.multiSigSpends {
+		multiSigSpend, _ := s.GetMultiSigSpend(sharedKey)
+		snapshot.multiSigSpends[sharedKey] = *multiSigSpend
+	}
+	return snapshot
+}
+
+// equal ignores accounts missing from either snapshot if they are empty, as
+// accounts are kept once created
+func (s *fakeBlockStateSnapshot) equal(other *fakeBlockStateSnapshot) bool {
+	for _, snapshots := range [][2]*fakeBlockStateSnapshot{{s, other}, {other, s}} {
+		for address, balance := range snapshots[0].balances {
+			if snapshots[1].balances[address] != balance || snapshots[1].multiSig[address] != snapshots[0].multiSig[address] {
+				return false
+			}
+		}
+	}
+	return s.multiSigAddresses == other.multiSigAddresses &&
+		reflect.DeepEqual(s.multiSigSpends, other.multiSigSpends)
+}
+
+func TestComputeBlockChangesMultiSig(t *testing.T) {
+	signatoryA := []byte{0x0a}
+	signatoryB := []byte{0x0b}
+	createTxHash := []byte{0x10}
+	spendTxHash := []byte{0x11}
+	multiSigAddress := misc.GetMultiSigAddress(createTxHash)
+	multiSigByteAddress, err := misc.ToByteAddress(multiSigAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sharedKey := misc.ToSizedHash(spendTxHash)
+
+	tests := []struct {
+		name                string
+		rules               []*rules.Rule
+		wantMultiSigBalance int64
+		wantRecipient       int64
+	}{
+		{
+			name:                "executed",
+			wantMultiSigBalance: 300,
+			wantRecipient:       200,
+		},
+		{
+			name: "executed with a frozen recipient",
+			rules: []*rules.Rule{{
+				Address: misc.ToStringAddress(recipientAddress),
+				Action:  rules.ActionFreeze,
+			}},
+			wantMultiSigBalance: 500,
+			wantRecipient:       0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := newTestEngine(t, tt.rules...)
+			state := newFakeBlockState()
+			blocks := []*generated.Block{
+				newTestBlock(0, newCoinbaseTX(senderAddress, 1000)),
+				newTestBlock(1, newMultiSigCreateTX(createTxHash, senderAddress,
+					[][]byte{signatoryA, signatoryB}, []uint32{1, 1}, 2)),
+				newTestBlock(2, newTransferTX(senderAddress, 0, [][]byte{multiSigByteAddress[:]}, []uint64{500})),
+				newTestBlock(3, newMultiSigSpendTX(spendTxHash, signatoryA, multiSigAddress,
+					[][]byte{recipientAddress}, []uint64{200}, 100)),
+				newTestBlock(4, newMultiSigVoteTX([]byte{0x12}, signatoryA, spendTxHash, false)),
+				newTestBlock(5, newMultiSigVoteTX([]byte{0x13}, signatoryB, spendTxHash, false)),
+			}
+
+			var snapshots []*fakeBlockStateSnapshot
+			for _, b := range blocks {
+				snapshots = append(snapshots, state.snapshot())
+				applyTestBlock(t, state, b, engine)
+			}
+
+			a, _ := state.GetAccountByAddress(multiSigAddress)
+			if !a.IsMultiSig || a.Balance != tt.wantMultiSigBalance {
+				t.Errorf("multisig account = (%v, %d), want (true, %d)", a.IsMultiSig, a.Balance, tt.wantMultiSigBalance)
+			}
+			if balance := state.balance(recipientAddress); balance != tt.wantRecipient {
+				t.Errorf("recipient balance = %d, want %d", balance, tt.wantRecipient)
+			}
+			multiSigSpend, err := state.GetMultiSigSpend(sharedKey)
+			if err != nil {
+				t.Fatalf("GetMultiSigSpend() error = %v", err)
+			}
+			if !multiSigSpend.Executed || multiSigSpend.ExecutedBlockNumber != 5 {
+				t.Errorf("spend executed = (%v, %d), want (true, 5)", multiSigSpend.Executed, multiSigSpend.ExecutedBlockNumber)
+			}
+			if votes := state.multiSigVotes[4]; len(votes) != 1 || votes[0].Weight != 1 {
+				t.Errorf("votes at block #4 = %v, want a single vote of weight 1", votes)
+			}
+
+			// Reverting the blocks one by one restores the state before each of them
+			for i := len(blocks) - 1; i > 0; i-- {
+				c, err := ComputeRevertChanges(models.NewBlockFromPBData(blocks[i]), state)
+				if err != nil {
+					t.Fatalf("ComputeRevertChanges(#%d) error = %v", i, err)
+				}
+				state.revert(c)
+				if !state.snapshot().equal(snapshots[i]) {
+					t.Errorf("state after reverting block #%d = %+v, want %+v", i, state.snapshot(), snapshots[i])
+				}
+			}
+		})
+	}
+}
+
+func TestComputeBlockChangesMultiSigNotFound(t *testing.T) {
+	engine := newTestEngine(t)
+	state := newFakeBlockState()
+	unknownAddress := misc.GetMultiSigAddress([]byte{0x20})
+
+	c := applyTestBlock(t, state, newTestBlock(0,
+		newMultiSigSpendTX([]byte{0x21}, senderAddress, unknownAddress, [][]byte{recipientAddress}, []uint64{1}, 10),
+		newMultiSigVoteTX([]byte{0x22}, senderAddress, []byte{0x23}, false),
+	), engine)
+	if len(c.MultiSigSpends) != 0 || len(c.MultiSigVotes) != 0 {
+		t.Errorf("changes = (%d spends, %d votes) for an unknown multisig address, want none",
+			len(c.MultiSigSpends), len(c.MultiSigVotes))
+	}
+}
+
+func TestComputeRevertChangesTransfer(t *testing.T) {
+	engine := newTestEngine(t)
+	state := newFakeBlockState()
+	genesis := newTestBlock(0)
+	genesis.GenesisBalance = []*generated.GenesisBalance{{Address: senderAddress, Balance: 1000}}
+	applyTestBlock(t, state, genesis, engine)
+	before := state.accounts[misc.ToStringAddress(senderAddress)]
+
+	b := newTestBlock(1, newTransferTX(senderAddress, 2, [][]byte{recipientAddress, recipientAddress}, []uint64{10, 20}))
+	applyTestBlock(t, state, b, engine)
+	recipient := state.accounts[misc.ToStringAddress(recipientAddress)]
+	if recipient.Balance != 30 || recipient.IncomingTxCount != 1 {
+		t.Errorf("recipient = (%d, %d incoming), want (30, 1 incoming)", recipient.Balance, recipient.IncomingTxCount)
+	}
+
+	c, err := ComputeRevertChanges(models.NewBlockFromPBData(b), state)
+	if err != nil {
+		t.Fatalf("ComputeRevertChanges() error = %v", err)
+	}
+	state.revert(c)
+	if after := state.accounts[misc.ToStringAddress(senderAddress)]; !reflect.DeepEqual(after, before) {
+		t.Errorf("sender after revert = %+v, want %+v", after, before)
+	}
+	if balance := state.balance(recipientAddress); balance != 0 {
+		t.Errorf("recipient balance after revert = %d, want 0", balance)
+	}
+}
@@ -2,15 +2,19 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"net/url"
 	"time"
 
+	"github.com/theQRL/qrl-rich-list-indexer/cache"
 	"github.com/theQRL/qrl-rich-list-indexer/config"
 	"github.com/theQRL/qrl-rich-list-indexer/log"
+	"github.com/theQRL/qrl-rich-list-indexer/rules"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.mongodb.org/mongo-driver/x/bsonx"
 )
 
@@ -22,16 +26,49 @@ type MongoDBProcessor struct {
 	config *config.Config
 	log    log.LoggerInterface
 
-	pac generated.PublicAPIClient
-
 	//lastBlock *Block
 	blocksCollection            *mongo.Collection
 	accountsCollection          *mongo.Collection
 	balanceChangeLogsCollection *mongo.Collection
+
+	tokensCollection                 *mongo.Collection
+	tokenHoldersCollection           *mongo.Collection
+	tokenBalanceChangeLogsCollection *mongo.Collection
+
+	slavesCollection            *mongo.Collection
+	multiSigAddressesCollection *mongo.Collection
+	multiSigSpendsCollection    *mongo.Collection
+	multiSigVotesCollection     *mongo.Collection
+
+	minersCollection      *mongo.Collection
+	minedBlocksCollection *mongo.Collection
+
+	proposalsCollection     *mongo.Collection
+	proposalVotesCollection *mongo.Collection
+
+	otsKeyUsagesCollection   *mongo.Collection
+	otsKeyStatusesCollection *mongo.Collection
+
+	transactionsCollection *mongo.Collection
+
+	rules                *rules.Engine
+	ruleAuditsCollection *mongo.Collection
+	ruleSetsCollection   *mongo.Collection
+
+	statsCollection             *mongo.Collection
+	richListSnapshotsCollection *mongo.Collection
+
+	schemaVersionCollection *mongo.Collection
+
+	accountLRUCache *cache.AccountLRUCache
 }
 
-func (m *MongoDBProcessor) SetPAC(pac generated.PublicAPIClient) {
-	m.pac = pac
+// withContext returns a copy of m whose reads and writes are made with ctx,
+// such as the session context of a transaction
+func (m *MongoDBProcessor) withContext(ctx context.Context) *MongoDBProcessor {
+	tm := *m
+	tm.ctx = ctx
+	return &tm
 }
 
 func (m *MongoDBProcessor) IsDataBaseExists(dbName string) (bool, error) {
@@ -52,6 +89,7 @@ func (m *MongoDBProcessor) IsCollectionExists(collectionName string) (bool, erro
 	if err != nil {
 		return false, err
 	}
+	defer cursor.Close(m.ctx)
 	for cursor.Next(m.ctx) {
 		next := &bsonx.Doc{}
 		err := cursor.Decode(next)
@@ -69,7 +107,7 @@ func (m *MongoDBProcessor) IsCollectionExists(collectionName string) (bool, erro
 			return true, nil
 		}
 	}
-	return false, nil
+	return false, cursor.Err()
 }
 
 func (m *MongoDBProcessor) CreateBlocksIndexes(found bool) error {
@@ -79,8 +117,9 @@ func (m *MongoDBProcessor) CreateBlocksIndexes(found bool) error {
 	}
 	_, err := m.blocksCollection.Indexes().CreateMany(context.Background(),
 		[]mongo.IndexModel{
-			{Keys: bson.M{"number": int32(-1)}},
+			{Keys: bson.M{"number": int32(-1)}, Options: options.Index().SetUnique(true)},
 			{Keys: bson.M{"hash": int32(-1)}},
+			{Keys: bson.M{"timestamp": int32(-1)}},
 		})
 	if err != nil {
 		m.log.Error("Error while modeling index for blocks",
@@ -97,7 +136,10 @@ func (m *MongoDBProcessor) CreateAccountsIndexes(found bool) error {
 	}
 	_, err := m.accountsCollection.Indexes().CreateMany(context.Background(),
 		[]mongo.IndexModel{
-			{Keys: bson.M{"address": int32(-1)}},
+			{Keys: bson.M{"address": int32(-1)}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.M{"balance": int32(-1)}},
+			{Keys: bson.D{{Key: "descriptor.hashFunction", Value: int32(1)}, {Key: "balance", Value: int32(-1)}}},
+			{Keys: bson.D{{Key: "descriptor.treeHeight", Value: int32(1)}, {Key: "balance", Value: int32(-1)}}},
 		})
 	if err != nil {
 		m.log.Error("Error while modeling index for accounts",
@@ -115,6 +157,7 @@ func (m *MongoDBProcessor) CreateBalanceChangeLogsIndexes(found bool) error {
 	_, err := m.balanceChangeLogsCollection.Indexes().CreateMany(context.Background(),
 		[]mongo.IndexModel{
 			{Keys: bson.M{"blockNumber": int32(-1)}},
+			{Keys: bson.D{{Key: "from", Value: int32(-1)}, {Key: "blockNumber", Value: int32(-1)}}},
 		})
 	if err != nil {
 		m.log.Error("Error while modeling index for balanceChangeLogs",
@@ -124,11 +167,366 @@ func (m *MongoDBProcessor) CreateBalanceChangeLogsIndexes(found bool) error {
 	return nil
 }
 
+func (m *MongoDBProcessor) CreateTokensIndexes(found bool) error {
+	m.tokensCollection = m.database.Collection("tokens")
+	if found {
+		return nil
+	}
+	_, err := m.tokensCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"txHash": int32(-1)}},
+			{Keys: bson.M{"symbol": int32(-1)}},
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for tokens",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateTokenHoldersIndexes(found bool) error {
+	m.tokenHoldersCollection = m.database.Collection("tokenHolders")
+	if found {
+		return nil
+	}
+	_, err := m.tokenHoldersCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.D{{Key: "tokenTxHash", Value: int32(-1)}, {Key: "address", Value: int32(-1)}}},
+			{Keys: bson.D{{Key: "tokenTxHash", Value: int32(-1)}, {Key: "balance", Value: int32(-1)}}},
+			{Keys: bson.M{"address": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for tokenHolders",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateTokenBalanceChangeLogsIndexes(found bool) error {
+	m.tokenBalanceChangeLogsCollection = m.database.Collection("tokenBalanceChangeLogs")
+	if found {
+		return nil
+	}
+	_, err := m.tokenBalanceChangeLogsCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for tokenBalanceChangeLogs",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateSlavesIndexes(found bool) error {
+	m.slavesCollection = m.database.Collection("slaves")
+	if found {
+		return nil
+	}
+	_, err := m.slavesCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"publicKey": int32(-1)}},
+			{Keys: bson.M{"address": int32(-1)}},
+			{Keys: bson.M{"masterAddress": int32(-1)}},
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for slaves",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateMultiSigAddressesIndexes(found bool) error {
+	m.multiSigAddressesCollection = m.database.Collection("multiSigAddresses")
+	if found {
+		return nil
+	}
+	_, err := m.multiSigAddressesCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"address": int32(-1)}},
+			{Keys: bson.M{"signatories": int32(-1)}},
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for multiSigAddresses",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateMultiSigSpendsIndexes(found bool) error {
+	m.multiSigSpendsCollection = m.database.Collection("multiSigSpends")
+	if found {
+		return nil
+	}
+	_, err := m.multiSigSpendsCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"sharedKey": int32(-1)}},
+			{Keys: bson.M{"multiSigAddress": int32(-1)}},
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+			{Keys: bson.M{"executedBlockNumber": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for multiSigSpends",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateMultiSigVotesIndexes(found bool) error {
+	m.multiSigVotesCollection = m.database.Collection("multiSigVotes")
+	if found {
+		return nil
+	}
+	_, err := m.multiSigVotesCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"sharedKey": int32(-1)}},
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for multiSigVotes",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateMinersIndexes(found bool) error {
+	m.minersCollection = m.database.Collection("miners")
+	if found {
+		return nil
+	}
+	_, err := m.minersCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"address": int32(-1)}},
+			{Keys: bson.M{"blocksMined": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for miners",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateMinedBlocksIndexes(found bool) error {
+	m.minedBlocksCollection = m.database.Collection("minedBlocks")
+	if found {
+		return nil
+	}
+	_, err := m.minedBlocksCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+			{Keys: bson.M{"miner": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for minedBlocks",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateProposalsIndexes(found bool) error {
+	m.proposalsCollection = m.database.Collection("proposals")
+	if found {
+		return nil
+	}
+	_, err := m.proposalsCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"sharedKey": int32(-1)}},
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+			{Keys: bson.M{"expiryBlockNumber": int32(-1)}},
+			{Keys: bson.M{"finalizedBlockNumber": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for proposals",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateProposalVotesIndexes(found bool) error {
+	m.proposalVotesCollection = m.database.Collection("proposalVotes")
+	if found {
+		return nil
+	}
+	_, err := m.proposalVotesCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.D{{Key: "sharedKey", Value: int32(-1)}, {Key: "blockNumber", Value: int32(1)}}},
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for proposalVotes",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateOTSKeyUsagesIndexes(found bool) error {
+	m.otsKeyUsagesCollection = m.database.Collection("otsKeyUsages")
+	if found {
+		return nil
+	}
+	_, err := m.otsKeyUsagesCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.D{{Key: "address", Value: int32(-1)}, {Key: "otsIndex", Value: int32(-1)}}},
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for otsKeyUsages",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateOTSKeyStatusesIndexes(found bool) error {
+	m.otsKeyStatusesCollection = m.database.Collection("otsKeyStatuses")
+	if found {
+		return nil
+	}
+	_, err := m.otsKeyStatusesCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"address": int32(-1)}},
+			{Keys: bson.M{"isReused": int32(-1)}},
+			{Keys: bson.M{"isNearExhaustion": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for otsKeyStatuses",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateTransactionsIndexes(found bool) error {
+	m.transactionsCollection = m.database.Collection("transactions")
+	if found {
+		return nil
+	}
+	_, err := m.transactionsCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"hash": int32(-1)}},
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+			{Keys: bson.D{{Key: "from", Value: int32(-1)}, {Key: "blockNumber", Value: int32(-1)}}},
+			{Keys: bson.D{{Key: "outputs.address", Value: int32(-1)}, {Key: "blockNumber", Value: int32(-1)}}},
+			{Keys: bson.D{{Key: "multiSigAddress", Value: int32(-1)}, {Key: "blockNumber", Value: int32(-1)}}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for transactions",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateRuleAuditsIndexes(found bool) error {
+	m.ruleAuditsCollection = m.database.Collection("ruleAudits")
+	if found {
+		return nil
+	}
+	_, err := m.ruleAuditsCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+			{Keys: bson.M{"address": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for ruleAudits",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateRuleSetsIndexes(found bool) error {
+	m.ruleSetsCollection = m.database.Collection("ruleSets")
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateRichListSnapshotsIndexes(found bool) error {
+	m.richListSnapshotsCollection = m.database.Collection("richListSnapshots")
+	if found {
+		return nil
+	}
+	_, err := m.richListSnapshotsCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"blockNumber": int32(-1)}},
+			{Keys: bson.M{"day": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for richListSnapshots",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateSchemaVersionIndexes(found bool) error {
+	m.schemaVersionCollection = m.database.Collection("schema_version")
+	return nil
+}
+
+func (m *MongoDBProcessor) CreateStatsIndexes(found bool) error {
+	m.statsCollection = m.database.Collection("stats")
+	if found {
+		return nil
+	}
+	_, err := m.statsCollection.Indexes().CreateMany(context.Background(),
+		[]mongo.IndexModel{
+			{Keys: bson.M{"name": int32(-1)}},
+		})
+	if err != nil {
+		m.log.Error("Error while modeling index for stats",
+			"Error", err)
+		return err
+	}
+	return nil
+}
+
 func (m *MongoDBProcessor) CreateIndexes() error {
 	collectionsLists := map[string]interface{}{
 		"blocks":            m.CreateBlocksIndexes,
 		"accounts":          m.CreateAccountsIndexes,
 		"balanceChangeLogs": m.CreateBalanceChangeLogsIndexes,
+
+		"tokens":                 m.CreateTokensIndexes,
+		"tokenHolders":           m.CreateTokenHoldersIndexes,
+		"tokenBalanceChangeLogs": m.CreateTokenBalanceChangeLogsIndexes,
+
+		"slaves":            m.CreateSlavesIndexes,
+		"multiSigAddresses": m.CreateMultiSigAddressesIndexes,
+		"multiSigSpends":    m.CreateMultiSigSpendsIndexes,
+		"multiSigVotes":     m.CreateMultiSigVotesIndexes,
+
+		"miners":      m.CreateMinersIndexes,
+		"minedBlocks": m.CreateMinedBlocksIndexes,
+
+		"proposals":     m.CreateProposalsIndexes,
+		"proposalVotes": m.CreateProposalVotesIndexes,
+
+		"otsKeyUsages":   m.CreateOTSKeyUsagesIndexes,
+		"otsKeyStatuses": m.CreateOTSKeyStatusesIndexes,
+
+		"transactions": m.CreateTransactionsIndexes,
+
+		"ruleAudits": m.CreateRuleAuditsIndexes,
+		"ruleSets":   m.CreateRuleSetsIndexes,
+
+		"stats":             m.CreateStatsIndexes,
+		"richListSnapshots": m.CreateRichListSnapshotsIndexes,
+
+		"schema_version": m.CreateSchemaVersionIndexes,
 	}
 
 	for collectionName, indexCreatorFunc := range collectionsLists {
@@ -145,43 +543,140 @@ func (m *MongoDBProcessor) CreateIndexes() error {
 	return nil
 }
 
+// GetMongoDBURI returns the connection string for mongoDBConfig, built from
+// its fields unless a full URI is configured
+func GetMongoDBURI(mongoDBConfig *config.MongoDBConfig) string {
+	if len(mongoDBConfig.URI) > 0 {
+		return mongoDBConfig.URI
+	}
+
+	mongoURL := &url.URL{
+		Scheme: "mongodb",
+		Host:   fmt.Sprintf("%s:%d", mongoDBConfig.Host, mongoDBConfig.Port),
+	}
+	query := url.Values{}
+	if len(mongoDBConfig.Username) > 0 {
+		mongoURL.User = url.UserPassword(mongoDBConfig.Username, mongoDBConfig.Password)
+		mongoURL.Path = "/" + mongoDBConfig.DBName
+	}
+	// Credentials may also come from the TLS client certificate, which needs authSource as well
+	if len(mongoDBConfig.AuthSource) > 0 {
+		query.Set("authSource", mongoDBConfig.AuthSource)
+	}
+	if len(mongoDBConfig.ReplicaSet) > 0 {
+		query.Set("replicaSet", mongoDBConfig.ReplicaSet)
+	}
+	if mongoDBConfig.TLS {
+		query.Set("tls", "true")
+		if len(mongoDBConfig.TLSCAFile) > 0 {
+			query.Set("tlsCAFile", mongoDBConfig.TLSCAFile)
+		}
+		if len(mongoDBConfig.TLSCertificateKeyFile) > 0 {
+			query.Set("tlsCertificateKeyFile", mongoDBConfig.TLSCertificateKeyFile)
+		}
+	}
+	if len(query) > 0 {
+		if len(mongoURL.Path) == 0 {
+			mongoURL.Path = "/"
+		}
+		mongoURL.RawQuery = query.Encode()
+	}
+	return mongoURL.String()
+}
+
+// CheckTransactionSupport returns an error unless the server client is
+// connected to can run the multi-document transactions used by ProcessBlock,
+// which requires a replica set on MongoDB 4.0+ or a sharded cluster on 4.2+
+func CheckTransactionSupport(ctx context.Context, client *mongo.Client) error {
+	err := client.Ping(ctx, readpref.Primary())
+	if err != nil {
+		return fmt.Errorf("unable to reach a primary MongoDB server: %w", err)
+	}
+
+	var hello struct {
+		SetName        string `bson:"setName"`
+		Msg            string `bson:"msg"`
+		MaxWireVersion int32  `bson:"maxWireVersion"`
+	}
+	admin := client.Database("admin")
+	err = admin.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		// Servers before 4.4.2 only know the legacy command
+		err = admin.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello)
+		if err != nil {
+			return fmt.Errorf("unable to query MongoDB server topology: %w", err)
+		}
+	}
+
+	switch {
+	case hello.Msg == "isdbgrid":
+		if hello.MaxWireVersion < 8 {
+			return errors.New("transactions on a sharded cluster require MongoDB 4.2 or later")
+		}
+	case len(hello.SetName) > 0:
+		if hello.MaxWireVersion < 7 {
+			return errors.New("transactions on a replica set require MongoDB 4.0 or later")
+		}
+	default:
+		return errors.New("MongoDB is running as a standalone server, which does not support transactions. " +
+			"Run it as a replica set, a single node replica set is enough")
+	}
+	return nil
+}
+
 func CreateMongoDBProcessor() (*MongoDBProcessor, error) {
 	m := &MongoDBProcessor{}
 	m.log = log.GetLogger()
 	m.config = config.GetConfig()
 
 	mongoDBConfig := m.config.GetMongoDBConfig()
-	dbName := mongoDBConfig.DBName
-	host := mongoDBConfig.Host
-	port := mongoDBConfig.Port
-	username := mongoDBConfig.Username
-	password := mongoDBConfig.Password
-
-	m.ctx, _ = context.WithTimeout(context.Background(), 60*time.Second)
-	mongoURL := fmt.Sprintf("mongodb://%s:%d", host, port)
-	if len(username) > 0 {
-		mongoURL = fmt.Sprintf(
-			"mongodb://%s:%s@%s:%d/%s",
-			username, password, host, port, dbName)
-	}
-	clientOptions := options.Client().ApplyURI(mongoURL)
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		time.Duration(mongoDBConfig.ConnectTimeoutSeconds)*time.Second)
+	defer cancel()
+
+	clientOptions := options.Client().ApplyURI(GetMongoDBURI(mongoDBConfig))
+	err := clientOptions.Validate()
+	if err != nil {
+		m.log.Error("Invalid MongoDB connection options",
+			"Error", err)
+		return nil, err
+	}
 	client, err := mongo.NewClient(clientOptions)
 	if err != nil {
-		fmt.Println(err)
+		m.log.Error("Failed to create MongoDB client",
+			"Error", err)
+		return nil, err
+	}
+	err = client.Connect(ctx)
+	if err != nil {
+		m.log.Error("Failed to connect to MongoDB",
+			"Error", err)
 		return nil, err
 	}
-	err = client.Connect(m.ctx)
+	err = CheckTransactionSupport(ctx, client)
 	if err != nil {
-		fmt.Println(err)
+		m.log.Error("MongoDB preflight check failed",
+			"Error", err)
+		client.Disconnect(context.Background())
 		return nil, err
 	}
 	m.ctx = context.TODO()
 	m.client = client
-	m.database = m.client.Database(dbName)
+	m.accountLRUCache = cache.NewAccountLRUCache(m.config.AccountCacheSize)
+	m.database = m.client.Database(mongoDBConfig.DBName)
 	err = m.CreateIndexes()
 	if err != nil {
 		return nil, err
 	}
 
+	m.rules, err = rules.LoadEngine(m.config.RulesFilePath)
+	if err != nil {
+		m.log.Error("Failed to load rules",
+			"RulesFilePath", m.config.RulesFilePath,
+			"Error", err)
+		return nil, err
+	}
+
 	return m, nil
 }
@@ -0,0 +1,235 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/config"
+	"github.com/theQRL/qrl-rich-list-indexer/db"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+	"github.com/theQRL/qrl-rich-list-indexer/log"
+	"github.com/theQRL/qrl-rich-list-indexer/rules"
+)
+
+// MemoryProcessor keeps the accounts, blocks and balance change logs in maps.
+// Reads return copies, so that the changes of a block only become visible
+// once the whole block has been applied.
+type MemoryProcessor struct {
+	lock sync.RWMutex
+
+	config *config.Config
+	log    log.LoggerInterface
+
+	rules *rules.Engine
+
+	blocks            map[int64]*models.Block
+	firstBlockNumber  int64
+	lastBlockNumber   int64
+	accounts          map[common.Address]*models.Account
+	balanceChangeLogs map[int64][]*models.BalanceChangeLog
+	balanceHistory    map[common.Address][]*models.BalanceChangeLog // Change logs of every address in block order
+
+	multiSigAddresses map[common.Address]*models.MultiSigAddress
+	multiSigSpends    map[common.Hash]*models.MultiSigSpend
+	multiSigVotes     map[int64][]*models.MultiSigVote
+}
+
+var _ db.Storage = (*MemoryProcessor)(nil)
+
+func copyAccount(a *models.Account) *models.Account {
+	c := *a
+	return &c
+}
+
+func copyMultiSigSpend(s *models.MultiSigSpend) *models.MultiSigSpend {
+	c := *s
+	c.Unvotes = append([]bool(nil), s.Unvotes...)
+	return &c
+}
+
+func (p *MemoryProcessor) GetLastBlock() (*models.Block, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.getBlockByNumber(p.lastBlockNumber)
+}
+
+func (p *MemoryProcessor) GetBlockByNumber(number int64) (*models.Block, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.getBlockByNumber(number)
+}
+
+func (p *MemoryProcessor) getBlockByNumber(number int64) (*models.Block, error) {
+	b, ok := p.blocks[number]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	c := *b
+	return &c, nil
+}
+
+func (p *MemoryProcessor) GetAccountByAddress(address common.Address) (*models.Account, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.getAccountByAddress(address)
+}
+
+func (p *MemoryProcessor) getAccountByAddress(address common.Address) (*models.Account, error) {
+	a, ok := p.accounts[address]
+	if !ok {
+		return models.NewAccount(address), nil
+	}
+	return copyAccount(a), nil
+}
+
+func (p *MemoryProcessor) GetBalanceChangeLogsByBlockNumber(blockNumber int64) ([]*models.BalanceChangeLog, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.getBalanceChangeLogsByBlockNumber(blockNumber)
+}
+
+func (p *MemoryProcessor) getBalanceChangeLogsByBlockNumber(blockNumber int64) ([]*models.BalanceChangeLog, error) {
+	var balanceChangeLogs []*models.BalanceChangeLog
+	for _, balanceChangeLog := range p.balanceChangeLogs[blockNumber] {
+		c := *balanceChangeLog
+		balanceChangeLogs = append(balanceChangeLogs, &c)
+	}
+	return balanceChangeLogs, nil
+}
+
+// GetRichList returns the accounts with non zero balance ordered by balance in
+// descending order, restricted to the address type matching filter if not nil.
+// Addresses excluded by rules at the last indexed block are left out.
+func (p *MemoryProcessor) GetRichList(filter *models.AddressTypeFilter, skip int64, limit int64) ([]*models.Account, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	excluded := make(map[common.Address]bool)
+	if b, ok := p.blocks[p.lastBlockNumber]; ok {
+		for _, address := range p.rules.ExcludedAddresses(b.GetNumber()) {
+			excluded[address] = true
+		}
+	}
+
+	var accounts []*models.Account
+	for addr, a := range p.accounts {
+		if a.Balance <= 0 || excluded[addr] {
+			continue
+		}
+		if filter != nil {
+			if a.Descriptor == nil {
+				continue
+			}
+			if filter.HashFunction != nil && a.Descriptor.HashFunction != *filter.HashFunction {
+				continue
+			}
+			if filter.TreeHeight != nil && a.Descriptor.TreeHeight != *filter.TreeHeight {
+				continue
+			}
+		}
+		accounts = append(accounts, copyAccount(a))
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		if accounts[i].Balance != accounts[j].Balance {
+			return accounts[i].Balance > accounts[j].Balance
+		}
+		return accounts[i].Address < accounts[j].Address
+	})
+
+	if skip >= int64(len(accounts)) {
+		return nil, nil
+	}
+	accounts = accounts[skip:]
+	if limit > 0 && limit < int64(len(accounts)) {
+		accounts = accounts[:limit]
+	}
+	return accounts, nil
+}
+
+// GetBalanceAt returns the balance of address at the end of the block at
+// height. Heights before the retained blocks return db.ErrHistoryNotRetained.
+func (p *MemoryProcessor) GetBalanceAt(address common.Address, height int64) (int64, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if len(p.blocks) == 0 {
+		return 0, db.ErrNotFound
+	}
+	// The balance before the first retained block is known from its change logs
+	if height < p.firstBlockNumber-1 {
+		return 0, db.ErrHistoryNotRetained
+	}
+
+	history := p.balanceHistory[address]
+	// Index of the first change log after height
+	i := sort.Search(len(history), func(i int) bool {
+		return history[i].BlockNumber > height
+	})
+	if i > 0 {
+		return history[i-1].BalanceAfter, nil
+	}
+	if i < len(history) {
+		return history[i].BalanceAfter - history[i].DeltaAmount, nil
+	}
+
+	a, err := p.getAccountByAddress(address)
+	if err != nil {
+		return 0, err
+	}
+	return a.Balance, nil
+}
+
+// GetBalanceHistory returns the balance changes of address, latest first
+func (p *MemoryProcessor) GetBalanceHistory(address common.Address, skip int64, limit int64) ([]*models.BalanceChangeLog, error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	var balanceChangeLogs []*models.BalanceChangeLog
+	history := p.balanceHistory[address]
+	for i := int64(len(history)) - 1 - skip; i >= 0; i-- {
+		if limit > 0 && int64(len(balanceChangeLogs)) == limit {
+			break
+		}
+		c := *history[i]
+		balanceChangeLogs = append(balanceChangeLogs, &c)
+	}
+	return balanceChangeLogs, nil
+}
+
+func CreateMemoryProcessor() (*MemoryProcessor, error) {
+	c := config.GetConfig()
+	engine, err := rules.LoadEngine(c.RulesFilePath)
+	if err != nil {
+		log.GetLogger().Error("Failed to load rules",
+			"RulesFilePath", c.RulesFilePath,
+			"Error", err)
+		return nil, err
+	}
+
+	return NewMemoryProcessor(engine), nil
+}
+
+// NewMemoryProcessor creates an empty MemoryProcessor applying the given rules
+func NewMemoryProcessor(engine *rules.Engine) *MemoryProcessor {
+	return &MemoryProcessor{
+		log:    log.GetLogger(),
+		config: config.GetConfig(),
+		rules:  engine,
+
+		blocks:            make(map[int64]*models.Block),
+		lastBlockNumber:   -1,
+		accounts:          make(map[common.Address]*models.Account),
+		balanceChangeLogs: make(map[int64][]*models.BalanceChangeLog),
+		balanceHistory:    make(map[common.Address][]*models.BalanceChangeLog),
+
+		multiSigAddresses: make(map[common.Address]*models.MultiSigAddress),
+		multiSigSpends:    make(map[common.Hash]*models.MultiSigSpend),
+		multiSigVotes:     make(map[int64][]*models.MultiSigVote),
+	}
+}
@@ -0,0 +1,166 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/theQRL/qrl-rich-list-indexer/db"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+	"github.com/theQRL/qrl-rich-list-indexer/rules"
+)
+
+var (
+	minerAddress     = []byte{0x01, 0x02, 0x03}
+	recipientAddress = []byte{0x04, 0x05, 0x06}
+)
+
+func newTestProcessor(t *testing.T, r ...*rules.Rule) *MemoryProcessor {
+	engine, err := rules.NewEngine(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewMemoryProcessor(engine)
+}
+
+// newTestBlock returns a block mining 100 to minerAddress, which is paid 10
+// to recipientAddress from block #1 onwards
+func newTestBlock(number uint64) *generated.Block {
+	b := &generated.Block{
+		Header: &generated.BlockHeader{
+			BlockNumber:      number,
+			HashHeader:       []byte{byte(number), 0x01},
+			HashHeaderPrev:   []byte{byte(number - 1), 0x01},
+			TimestampSeconds: 1600000000 + number*60,
+		},
+		Transactions: []*generated.Transaction{{
+			TransactionHash: []byte{byte(number), 0x02},
+			TransactionType: &generated.Transaction_Coinbase{
+				Coinbase: &generated.Transaction_CoinBase{AddrTo: minerAddress, Amount: 100},
+			},
+		}},
+	}
+	if number > 0 {
+		b.Transactions = append(b.Transactions, &generated.Transaction{
+			MasterAddr:      minerAddress,
+			TransactionHash: []byte{byte(number), 0x03},
+			TransactionType: &generated.Transaction_Transfer_{
+				Transfer: &generated.Transaction_Transfer{AddrsTo: [][]byte{recipientAddress}, Amounts: []uint64{10}},
+			},
+		})
+	}
+	return b
+}
+
+func processTestBlocks(t *testing.T, p *MemoryProcessor, from uint64, to uint64) {
+	var blocks []*generated.Block
+	for number := from; number <= to; number++ {
+		blocks = append(blocks, newTestBlock(number))
+	}
+	if err := p.ProcessBlocks(blocks); err != nil {
+		t.Fatalf("ProcessBlocks(%d, %d) error = %v", from, to, err)
+	}
+}
+
+func checkBalance(t *testing.T, p *MemoryProcessor, address []byte, want int64) {
+	a, err := p.GetAccountByAddress(misc.ToStringAddress(address))
+	if err != nil {
+		t.Fatalf("GetAccountByAddress() error = %v", err)
+	}
+	if a.Balance != want {
+		t.Errorf("Balance of %s = %d, want %d", a.Address, a.Balance, want)
+	}
+}
+
+func TestProcessAndRevertBlocks(t *testing.T) {
+	p := newTestProcessor(t)
+	if _, err := p.GetLastBlock(); err != db.ErrNotFound {
+		t.Fatalf("GetLastBlock() of an empty index error = %v, want ErrNotFound", err)
+	}
+
+	processTestBlocks(t, p, 0, 5)
+	checkBalance(t, p, minerAddress, 550)
+	checkBalance(t, p, recipientAddress, 50)
+
+	for i := 0; i < 3; i++ {
+		if err := p.RevertLastBlock(); err != nil {
+			t.Fatalf("RevertLastBlock() error = %v", err)
+		}
+	}
+	if b, err := p.GetLastBlock(); err != nil || b.Number != 2 {
+		t.Fatalf("GetLastBlock() = (%v, %v) after reverting 3 blocks, want block #2", b, err)
+	}
+	checkBalance(t, p, minerAddress, 280)
+	checkBalance(t, p, recipientAddress, 20)
+
+	history, err := p.GetBalanceHistory(misc.ToStringAddress(recipientAddress), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].BlockNumber != 2 || history[0].BalanceAfter != 20 {
+		t.Errorf("GetBalanceHistory() = %v, want the change logs of blocks #2 and #1", history)
+	}
+
+	// The reverted blocks are applied again on top
+	processTestBlocks(t, p, 3, 5)
+	checkBalance(t, p, minerAddress, 550)
+	checkBalance(t, p, recipientAddress, 50)
+}
+
+func TestReadsReturnCopies(t *testing.T) {
+	p := newTestProcessor(t)
+	processTestBlocks(t, p, 0, 1)
+
+	a, err := p.GetAccountByAddress(misc.ToStringAddress(minerAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.UpdateBalance(1000)
+	checkBalance(t, p, minerAddress, 190)
+}
+
+func TestPruneBeyondReOrgLimit(t *testing.T) {
+	p := newTestProcessor(t)
+	p.config.ReOrgLimit = 3
+	processTestBlocks(t, p, 0, 9)
+
+	if b, err := p.GetBlockByNumber(7); err != nil || b.Number != 7 {
+		t.Errorf("GetBlockByNumber(7) = (%v, %v), want block #7", b, err)
+	}
+	if _, err := p.GetBlockByNumber(6); err != db.ErrNotFound {
+		t.Errorf("GetBlockByNumber(6) error = %v, want ErrNotFound", err)
+	}
+
+	address := misc.ToStringAddress(recipientAddress)
+	tests := []struct {
+		height      int64
+		wantBalance int64
+		wantErr     error
+	}{
+		{height: 5, wantErr: db.ErrHistoryNotRetained},
+		{height: 6, wantBalance: 60},
+		{height: 8, wantBalance: 80},
+		{height: 9, wantBalance: 90},
+	}
+	for _, tt := range tests {
+		balance, err := p.GetBalanceAt(address, tt.height)
+		if balance != tt.wantBalance || err != tt.wantErr {
+			t.Errorf("GetBalanceAt(%d) = (%d, %v), want (%d, %v)", tt.height, balance, err, tt.wantBalance, tt.wantErr)
+		}
+	}
+}
+
+func TestGetRichListExcludesAddresses(t *testing.T) {
+	p := newTestProcessor(t, &rules.Rule{
+		Address: misc.ToStringAddress(minerAddress),
+		Action:  rules.ActionExclude,
+	})
+	processTestBlocks(t, p, 0, 2)
+
+	accounts, err := p.GetRichList(nil, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 || accounts[0].Address != misc.ToStringAddress(recipientAddress) {
+		t.Errorf("GetRichList() = %v, want only the recipient", accounts)
+	}
+}
@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"encoding/hex"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+)
+
+// view reads the state of a MemoryProcessor whose lock is already held
+type view struct {
+	p *MemoryProcessor
+}
+
+var _ db.BlockState = view{}
+
+func (v view) GetAccountByAddress(address common.Address) (*models.Account, error) {
+	return v.p.getAccountByAddress(address)
+}
+
+func (v view) GetBalanceChangeLogsByBlockNumber(blockNumber int64) ([]*models.BalanceChangeLog, error) {
+	return v.p.getBalanceChangeLogsByBlockNumber(blockNumber)
+}
+
+func (v view) GetMultiSigAddress(address common.Address) (*models.MultiSigAddress, error) {
+	a, ok := v.p.multiSigAddresses[address]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return a, nil
+}
+
+func (v view) GetMultiSigAddressesByBlockNumber(blockNumber int64) ([]*models.MultiSigAddress, error) {
+	var multiSigAddresses []*models.MultiSigAddress
+	for _, a := range v.p.multiSigAddresses {
+		if a.BlockNumber == blockNumber {
+			multiSigAddresses = append(multiSigAddresses, a)
+		}
+	}
+	return multiSigAddresses, nil
+}
+
+func (v view) GetMultiSigSpend(sharedKey common.Hash) (*models.MultiSigSpend, error) {
+	s, ok := v.p.multiSigSpends[sharedKey]
+	if !ok {
+		return nil, db.ErrNotFound
+	}
+	return copyMultiSigSpend(s), nil
+}
+
+func (v view) GetMultiSigSpendsByExecutedBlockNumber(blockNumber int64) ([]*models.MultiSigSpend, error) {
+	var multiSigSpends []*models.MultiSigSpend
+	for _, s := range v.p.multiSigSpends {
+		if s.Executed && s.ExecutedBlockNumber == blockNumber {
+			multiSigSpends = append(multiSigSpends, copyMultiSigSpend(s))
+		}
+	}
+	return multiSigSpends, nil
+}
+
+func (v view) GetMultiSigVotesByBlockNumber(blockNumber int64) ([]*models.MultiSigVote, error) {
+	return v.p.multiSigVotes[blockNumber], nil
+}
+
+// writeChanges stores the accounts and multisig spends updated by changes. It
+// is only called once the changes of a block have been computed completely.
+func (p *MemoryProcessor) writeChanges(changes *db.BlockChanges) {
+	for addr, account := range changes.Accounts {
+		p.accounts[addr] = account
+	}
+	for sharedKey, multiSigSpend := range changes.MultiSigSpends {
+		p.multiSigSpends[sharedKey] = multiSigSpend
+	}
+}
+
+func (p *MemoryProcessor) ProcessBlock(b *generated.Block) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	changes, err := db.ComputeBlockChanges(b, view{p}, p.rules)
+	if err != nil {
+		p.log.Error("[ProcessBlock] Failed to ComputeBlockChanges",
+			"Error", err.Error())
+		return err
+	}
+	blockNumber := changes.Block.Number
+
+	reOrgLimit := common.BLOCKZERO + p.config.ReOrgLimit
+	if !p.config.ArchiveMode && uint64(blockNumber) > reOrgLimit {
+		removeBlockNumber := int64(uint64(blockNumber) - reOrgLimit)
+		for _, balanceChangeLog := range p.balanceChangeLogs[removeBlockNumber] {
+			history := p.balanceHistory[balanceChangeLog.Address]
+			if len(history) == 1 {
+				delete(p.balanceHistory, balanceChangeLog.Address)
+			} else {
+				p.balanceHistory[balanceChangeLog.Address] = history[1:]
+			}
+		}
+		delete(p.blocks, removeBlockNumber)
+		delete(p.balanceChangeLogs, removeBlockNumber)
+		p.firstBlockNumber = removeBlockNumber + 1
+	}
+
+	if len(p.blocks) == 0 {
+		p.firstBlockNumber = blockNumber
+	}
+	p.blocks[blockNumber] = changes.Block
+	p.lastBlockNumber = blockNumber
+
+	p.writeChanges(changes)
+	for addr, balanceChangeLog := range changes.BalanceChangeLogs {
+		p.balanceChangeLogs[blockNumber] = append(p.balanceChangeLogs[blockNumber], balanceChangeLog)
+		p.balanceHistory[addr] = append(p.balanceHistory[addr], balanceChangeLog)
+	}
+	for _, multiSigAddress := range changes.MultiSigAddresses {
+		p.multiSigAddresses[multiSigAddress.Address] = multiSigAddress
+	}
+	if len(changes.MultiSigVotes) > 0 {
+		p.multiSigVotes[blockNumber] = changes.MultiSigVotes
+	}
+
+	p.log.Info("Processed",
+		"Block #", b.Header.BlockNumber,
+		"HeaderHash", hex.EncodeToString(b.Header.HashHeader))
+	return nil
+}
+
+// ProcessBlocks applies the blocks one after the other. Each block is applied
+// completely or not at all, so a failure leaves the state at the end of the
+// block before the failing one.
+func (p *MemoryProcessor) ProcessBlocks(blocks []*generated.Block) error {
+	for _, b := range blocks {
+		err := p.ProcessBlock(b)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *MemoryProcessor) RevertLastBlock() error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	b, err := p.getBlockByNumber(p.lastBlockNumber)
+	if err != nil {
+		p.log.Error("[RevertLastBlock] failed to get last block",
+			"error", err)
+		return err
+	}
+
+	changes, err := db.ComputeRevertChanges(b, view{p})
+	if err != nil {
+		p.log.Error("[RevertLastBlock] Failed to ComputeRevertChanges",
+			"Error", err.Error())
+		return err
+	}
+
+	p.writeChanges(changes)
+
+	delete(p.blocks, b.Number)
+	p.lastBlockNumber = b.Number - 1
+	for _, balanceChangeLog := range p.balanceChangeLogs[b.Number] {
+		history := p.balanceHistory[balanceChangeLog.Address]
+		if len(history) == 1 {
+			delete(p.balanceHistory, balanceChangeLog.Address)
+		} else {
+			p.balanceHistory[balanceChangeLog.Address] = history[:len(history)-1]
+		}
+	}
+	delete(p.balanceChangeLogs, b.Number)
+	delete(p.multiSigVotes, b.Number)
+	for addr, multiSigAddress := range p.multiSigAddresses {
+		if multiSigAddress.BlockNumber == b.Number {
+			delete(p.multiSigAddresses, addr)
+		}
+	}
+	for sharedKey, multiSigSpend := range p.multiSigSpends {
+		if multiSigSpend.BlockNumber == b.Number {
+			delete(p.multiSigSpends, sharedKey)
+		}
+	}
+
+	p.log.Info("Reverted",
+		"Block #", b.Number,
+		"HeaderHash", b.Hash.ToString())
+	return nil
+}
@@ -0,0 +1,521 @@
+package db
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migration upgrades the schema of an existing database to Version. Index
+// creators only run for collections which do not exist yet, so every change
+// to an existing collection has to be added here as well.
+type Migration struct {
+	Version     int64
+	Description string
+	Up          func(m *MongoDBProcessor) error
+}
+
+// Migrations must be kept in ascending order of Version, and released
+// migrations must never be changed
+var Migrations = []*Migration{
+	{
+		Version:     1,
+		Description: "Create address type indexes on accounts",
+		Up:          migrateAccountsAddressTypeIndexes,
+	},
+	{
+		Version:     2,
+		Description: "Tag accounts with their address descriptor",
+		Up:          migrateAccountsDescriptor,
+	},
+	{
+		Version:     3,
+		Description: "Backfill stats from accounts",
+		Up:          migrateStats,
+	},
+	{
+		Version:     4,
+		Description: "Repair duplicate accounts and blocks, and make address and number unique",
+		Up:          migrateUniqueIndexes,
+	},
+	{
+		Version:     5,
+		Description: "Index balanceChangeLogs by address and backfill balanceAfter",
+		Up:          migrateBalanceAfter,
+	},
+	{
+		Version:     6,
+		Description: "Index blocks by timestamp",
+		Up:          migrateBlocksTimestampIndex,
+	},
+	{
+		Version:     7,
+		Description: "Index multi sig spend transactions by multi sig address and tag them as pending until executed",
+		Up:          migrateMultiSigSpendTransactions,
+	},
+	{
+		Version:     8,
+		Description: "Index accounts by balance",
+		Up:          migrateAccountsBalanceIndex,
+	},
+}
+
+func migrateAccountsAddressTypeIndexes(m *MongoDBProcessor) error {
+	_, err := m.accountsCollection.Indexes().CreateMany(m.ctx,
+		[]mongo.IndexModel{
+			{Keys: bson.D{{Key: "descriptor.hashFunction", Value: int32(1)}, {Key: "balance", Value: int32(-1)}}},
+			{Keys: bson.D{{Key: "descriptor.treeHeight", Value: int32(1)}, {Key: "balance", Value: int32(-1)}}},
+		})
+	return err
+}
+
+func migrateAccountsDescriptor(m *MongoDBProcessor) error {
+	const batchSize = 1000
+
+	o := &options.FindOptions{}
+	o.SetProjection(bson.M{"address": 1})
+	cursor, err := m.accountsCollection.Find(m.ctx, bson.M{"descriptor": bson.M{"$exists": false}}, o)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(m.ctx)
+
+	var operations []mongo.WriteModel
+	for cursor.Next(m.ctx) {
+		a := &models.Account{}
+		err = cursor.Decode(a)
+		if err != nil {
+			return err
+		}
+		descriptor := models.NewAddressDescriptor(a.Address)
+		if descriptor == nil {
+			continue
+		}
+		operation := mongo.NewUpdateOneModel()
+		operation.SetFilter(bson.M{"address": a.Address})
+		operation.SetUpdate(bson.M{"$set": bson.M{"descriptor": descriptor}})
+		operations = append(operations, operation)
+
+		if len(operations) == batchSize {
+			if _, err := m.accountsCollection.BulkWrite(m.ctx, operations); err != nil {
+				return err
+			}
+			operations = nil
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if len(operations) > 0 {
+		if _, err := m.accountsCollection.BulkWrite(m.ctx, operations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateStats(m *MongoDBProcessor) error {
+	var statsOperations []mongo.WriteModel
+
+	b, err := m.GetLastBlock()
+	if err == ErrNotFound {
+		// Nothing indexed yet, stats will be maintained from the first block
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	cursor, err := m.accountsCollection.Aggregate(m.ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": nil, "totalSupply": bson.M{"$sum": "$balance"}}}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(m.ctx)
+
+	totalSupply := int64(0)
+	if cursor.Next(m.ctx) {
+		result := &struct {
+			TotalSupply int64 `bson:"totalSupply"`
+		}{}
+		err = cursor.Decode(result)
+		if err != nil {
+			return err
+		}
+		totalSupply = result.TotalSupply
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	nonZeroHolders, err := m.accountsCollection.CountDocuments(m.ctx, bson.M{"balance": bson.M{"$gt": 0}})
+	if err != nil {
+		return err
+	}
+
+	statsCache := map[string]int64{
+		models.StatsTotalSupply:       totalSupply,
+		models.StatsNonZeroHolders:    nonZeroHolders,
+		models.StatsLastIndexedHeight: b.Number,
+	}
+	for _, threshold := range m.config.HolderThresholds {
+		holdersAbove, err := m.accountsCollection.CountDocuments(m.ctx, bson.M{"balance": bson.M{"$gt": threshold}})
+		if err != nil {
+			return err
+		}
+		statsCache[models.StatsHoldersAbove(threshold)] = holdersAbove
+	}
+
+	for name, value := range statsCache {
+		operation := mongo.NewUpdateOneModel()
+		operation.SetUpsert(true)
+		operation.SetFilter(bson.M{"name": name})
+		operation.SetUpdate(bson.M{"$set": models.NewStats(name, value)})
+		statsOperations = append(statsOperations, operation)
+	}
+
+	return m.client.UseSession(m.ctx, func(sctx mongo.SessionContext) error {
+		topHoldersStatsOperations, err := m.GetTopHoldersStatsOperations(sctx, b.Number)
+		if err != nil {
+			return err
+		}
+		statsOperations = append(statsOperations, topHoldersStatsOperations...)
+		_, err = m.statsCollection.BulkWrite(sctx, statsOperations)
+		return err
+	})
+}
+
+// RemoveDuplicates keeps a single document for every value of field in
+// collection, the first one in sort order, and deletes the other ones
+func (m *MongoDBProcessor) RemoveDuplicates(collection *mongo.Collection, field string, sort bson.D) (int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: sort}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$" + field,
+			"ids":   bson.M{"$push": "$_id"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}
+	cursor, err := collection.Aggregate(m.ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(m.ctx)
+
+	removed := int64(0)
+	for cursor.Next(m.ctx) {
+		duplicates := &struct {
+			Value interface{}   `bson:"_id"`
+			IDs   []interface{} `bson:"ids"`
+		}{}
+		err = cursor.Decode(duplicates)
+		if err != nil {
+			return removed, err
+		}
+
+		m.log.Warn("Removing duplicate documents",
+			"collection", collection.Name(),
+			field, duplicates.Value,
+			"count", len(duplicates.IDs)-1)
+		result, err := collection.DeleteMany(m.ctx, bson.M{"_id": bson.M{"$in": duplicates.IDs[1:]}})
+		if err != nil {
+			return removed, err
+		}
+		removed += result.DeletedCount
+	}
+
+	return removed, cursor.Err()
+}
+
+// ensureUniqueIndex replaces the non unique descending index on field, created
+// by older versions, with a unique one
+func (m *MongoDBProcessor) ensureUniqueIndex(collection *mongo.Collection, field string) error {
+	name := field + "_-1"
+
+	cursor, err := collection.Indexes().List(m.ctx)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		index := &struct {
+			Name   string `bson:"name"`
+			Unique bool   `bson:"unique"`
+		}{}
+		err = cursor.Decode(index)
+		if err != nil {
+			return err
+		}
+		if index.Name != name {
+			continue
+		}
+		if index.Unique {
+			return nil
+		}
+		if _, err := collection.Indexes().DropOne(m.ctx, name); err != nil {
+			return err
+		}
+		break
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	_, err = collection.Indexes().CreateOne(m.ctx,
+		mongo.IndexModel{Keys: bson.M{field: int32(-1)}, Options: options.Index().SetUnique(true)})
+	return err
+}
+
+func migrateUniqueIndexes(m *MongoDBProcessor) error {
+	// The most recently active copy of an account is the one updated by the last block
+	_, err := m.RemoveDuplicates(m.accountsCollection, "address",
+		bson.D{{Key: "lastActiveBlockNumber", Value: -1}, {Key: "_id", Value: -1}})
+	if err != nil {
+		return err
+	}
+	_, err = m.RemoveDuplicates(m.blocksCollection, "number", bson.D{{Key: "_id", Value: -1}})
+	if err != nil {
+		return err
+	}
+
+	err = m.ensureUniqueIndex(m.accountsCollection, "address")
+	if err != nil {
+		return err
+	}
+	err = m.ensureUniqueIndex(m.blocksCollection, "number")
+	if err != nil {
+		return err
+	}
+
+	_, err = m.accountsCollection.Indexes().CreateOne(m.ctx,
+		mongo.IndexModel{Keys: bson.M{"balance": int32(-1)}})
+	return err
+}
+
+func migrateMultiSigSpendTransactions(m *MongoDBProcessor) error {
+	const batchSize = 1000
+
+	_, err := m.transactionsCollection.Indexes().CreateOne(m.ctx,
+		mongo.IndexModel{Keys: bson.D{{Key: "multiSigAddress", Value: int32(-1)}, {Key: "blockNumber", Value: int32(-1)}}})
+	if err != nil {
+		return err
+	}
+
+	// Spends are pending unless their vote state records them as executed
+	_, err = m.transactionsCollection.UpdateMany(m.ctx,
+		bson.M{"type": models.TransactionTypeMultiSigSpend}, bson.M{"$set": bson.M{"pending": true}})
+	if err != nil {
+		return err
+	}
+
+	cursor, err := m.multiSigSpendsCollection.Find(m.ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(m.ctx)
+
+	var operations []mongo.WriteModel
+	for cursor.Next(m.ctx) {
+		s := &models.MultiSigSpend{}
+		err = cursor.Decode(s)
+		if err != nil {
+			return err
+		}
+		update := bson.M{"$set": bson.M{"multiSigAddress": s.MultiSigAddress}}
+		if s.Executed {
+			update["$unset"] = bson.M{"pending": ""}
+		}
+		operation := mongo.NewUpdateOneModel()
+		operation.SetFilter(bson.M{"hash": s.SharedKey})
+		operation.SetUpdate(update)
+		operations = append(operations, operation)
+
+		if len(operations) == batchSize {
+			if _, err := m.transactionsCollection.BulkWrite(m.ctx, operations); err != nil {
+				return err
+			}
+			operations = nil
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	if len(operations) > 0 {
+		if _, err := m.transactionsCollection.BulkWrite(m.ctx, operations); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateBlocksTimestampIndex only creates the index, the header details of
+// blocks indexed before are not known without the node and are left empty
+func migrateBlocksTimestampIndex(m *MongoDBProcessor) error {
+	_, err := m.blocksCollection.Indexes().CreateOne(m.ctx,
+		mongo.IndexModel{Keys: bson.M{"timestamp": int32(-1)}})
+	return err
+}
+
+// migrateAccountsBalanceIndex creates the index the top holders are ranked by
+// on every block. It is a no-op on databases where version 4 created it.
+func migrateAccountsBalanceIndex(m *MongoDBProcessor) error {
+	_, err := m.accountsCollection.Indexes().CreateOne(m.ctx,
+		mongo.IndexModel{Keys: bson.M{"balance": int32(-1)}})
+	return err
+}
+
+func migrateBalanceAfter(m *MongoDBProcessor) error {
+	_, err := m.balanceChangeLogsCollection.Indexes().CreateOne(m.ctx,
+		mongo.IndexModel{Keys: bson.D{{Key: "from", Value: int32(-1)}, {Key: "blockNumber", Value: int32(-1)}}})
+	if err != nil {
+		return err
+	}
+
+	addresses, err := m.balanceChangeLogsCollection.Distinct(m.ctx, "from",
+		bson.M{"balanceAfter": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+
+	for _, address := range addresses {
+		addr, ok := address.(string)
+		if !ok {
+			continue
+		}
+		a, err := m.GetAccountByAddress(common.Address(addr))
+		if err != nil {
+			return err
+		}
+
+		// Walk back from the current balance, undoing one block at a time
+		balanceChangeLogs, err := m.GetBalanceHistory(a.Address, 0, 0)
+		if err != nil {
+			return err
+		}
+		var operations []mongo.WriteModel
+		balance := a.Balance
+		for _, balanceChangeLog := range balanceChangeLogs {
+			operation := mongo.NewUpdateOneModel()
+			operation.SetFilter(bson.M{"from": a.Address, "blockNumber": balanceChangeLog.BlockNumber})
+			operation.SetUpdate(bson.M{"$set": bson.M{"balanceAfter": balance}})
+			operations = append(operations, operation)
+			balance -= balanceChangeLog.DeltaAmount
+		}
+		if len(operations) > 0 {
+			if _, err := m.balanceChangeLogsCollection.BulkWrite(m.ctx, operations); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *MongoDBProcessor) GetSchemaVersion() (int64, error) {
+	result := m.schemaVersionCollection.FindOne(m.ctx, bson.M{})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return 0, nil
+	} else if result.Err() != nil {
+		return 0, result.Err()
+	}
+
+	s := &models.SchemaVersion{}
+	err := result.Decode(s)
+	if err != nil {
+		return 0, err
+	}
+	return s.Version, nil
+}
+
+// Schema version from which every account is tagged with its address descriptor
+const accountsDescriptorSchemaVersion = 2
+
+// requireSchemaVersion returns ErrMigrationPending unless the migrations up to
+// version have been applied
+func (m *MongoDBProcessor) requireSchemaVersion(version int64) error {
+	schemaVersion, err := m.GetSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if schemaVersion < version {
+		return ErrMigrationPending
+	}
+	return nil
+}
+
+func (m *MongoDBProcessor) SaveSchemaVersion(version int64) error {
+	o := options.Replace().SetUpsert(true)
+	_, err := m.schemaVersionCollection.ReplaceOne(m.ctx, bson.M{}, models.NewSchemaVersion(version), o)
+	return err
+}
+
+// GetPendingMigrations returns the migrations newer than the schema version
+// of the database, in the order they have to be applied
+func (m *MongoDBProcessor) GetPendingMigrations() ([]*Migration, error) {
+	var pendingMigrations []*Migration
+
+	version, err := m.GetSchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range Migrations {
+		if migration.Version > version {
+			pendingMigrations = append(pendingMigrations, migration)
+		}
+	}
+	return pendingMigrations, nil
+}
+
+// Migrate applies the pending migrations in order, saving the schema version
+// after each of them, so that a failed migration is retried on the next run.
+// With dryRun the pending migrations are only logged.
+func (m *MongoDBProcessor) Migrate(dryRun bool) error {
+	pendingMigrations, err := m.GetPendingMigrations()
+	if err != nil {
+		m.log.Error("[Migrate] Failed to GetPendingMigrations",
+			"Error", err.Error())
+		return err
+	}
+
+	for _, migration := range pendingMigrations {
+		if dryRun {
+			m.log.Info("Pending migration",
+				"version", migration.Version,
+				"description", migration.Description)
+			continue
+		}
+
+		m.log.Info("Applying migration",
+			"version", migration.Version,
+			"description", migration.Description)
+		err = migration.Up(m)
+		if err != nil {
+			m.log.Error("[Migrate] Failed to apply migration",
+				"version", migration.Version,
+				"Error", err.Error())
+			return err
+		}
+		err = m.SaveSchemaVersion(migration.Version)
+		if err != nil {
+			m.log.Error("[Migrate] Failed to SaveSchemaVersion",
+				"version", migration.Version,
+				"Error", err.Error())
+			return err
+		}
+	}
+
+	if len(pendingMigrations) == 0 {
+		m.log.Info("Schema is up to date")
+	} else if !dryRun {
+		// Migrations may have rewritten accounts
+		m.accountLRUCache.Purge()
+	}
+	return nil
+}
@@ -3,17 +3,53 @@ package models
 import "github.com/theQRL/qrl-rich-list-indexer/common"
 
 type Account struct {
-	Address common.Address `json:"address" bson:"address"`
-	Balance int64          `json:"balance" bson:"balance"`
+	Address    common.Address `json:"address" bson:"address"`
+	Balance    int64          `json:"balance" bson:"balance"`
+	IsMultiSig bool           `json:"isMultiSig" bson:"isMultiSig"`
+
+	Descriptor *AddressDescriptor `json:"descriptor,omitempty" bson:"descriptor,omitempty"`
+
+	FirstSeenBlockNumber  int64  `json:"firstSeenBlockNumber" bson:"firstSeenBlockNumber"`
+	FirstSeenTimestamp    uint64 `json:"firstSeenTimestamp" bson:"firstSeenTimestamp"`
+	LastActiveBlockNumber int64  `json:"lastActiveBlockNumber" bson:"lastActiveBlockNumber"`
+	LastActiveTimestamp   uint64 `json:"lastActiveTimestamp" bson:"lastActiveTimestamp"`
+
+	AccountActivity `bson:",inline"`
 }
 
 func (a *Account) UpdateBalance(balance int64) {
 	a.Balance += balance
 }
 
+func (a *Account) UpdateActivity(activity *AccountActivity) {
+	a.AccountActivity.Add(activity)
+}
+
+// Touch marks the account as active at the given block
+func (a *Account) Touch(blockNumber int64, timestamp uint64) {
+	if a.FirstSeenTimestamp == 0 {
+		a.FirstSeenBlockNumber = blockNumber
+		a.FirstSeenTimestamp = timestamp
+	}
+	a.LastActiveBlockNumber = blockNumber
+	a.LastActiveTimestamp = timestamp
+}
+
+// RevertActivity undoes the activity recorded by balanceChangeLog
+func (a *Account) RevertActivity(balanceChangeLog *BalanceChangeLog) {
+	a.AccountActivity.Sub(&balanceChangeLog.Activity)
+	a.LastActiveBlockNumber = balanceChangeLog.PrevLastActiveBlockNumber
+	a.LastActiveTimestamp = balanceChangeLog.PrevLastActiveTimestamp
+	if a.FirstSeenBlockNumber == balanceChangeLog.BlockNumber {
+		a.FirstSeenBlockNumber = 0
+		a.FirstSeenTimestamp = 0
+	}
+}
+
 func NewAccount(address common.Address) *Account {
 	return &Account{
-		Address: address,
-		Balance: 0,
+		Address:    address,
+		Balance:    0,
+		Descriptor: NewAddressDescriptor(address),
 	}
 }
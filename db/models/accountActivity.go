@@ -0,0 +1,40 @@
+package models
+
+type AccountActivity struct {
+	IncomingTxCount int64 `json:"incomingTxCount" bson:"incomingTxCount"`
+	OutgoingTxCount int64 `json:"outgoingTxCount" bson:"outgoingTxCount"`
+	TotalReceived   int64 `json:"totalReceived" bson:"totalReceived"`
+	TotalSent       int64 `json:"totalSent" bson:"totalSent"`
+	TotalFeesPaid   int64 `json:"totalFeesPaid" bson:"totalFeesPaid"`
+}
+
+func (a *AccountActivity) Add(activity *AccountActivity) {
+	a.IncomingTxCount += activity.IncomingTxCount
+	a.OutgoingTxCount += activity.OutgoingTxCount
+	a.TotalReceived += activity.TotalReceived
+	a.TotalSent += activity.TotalSent
+	a.TotalFeesPaid += activity.TotalFeesPaid
+}
+
+func (a *AccountActivity) Sub(activity *AccountActivity) {
+	a.IncomingTxCount -= activity.IncomingTxCount
+	a.OutgoingTxCount -= activity.OutgoingTxCount
+	a.TotalReceived -= activity.TotalReceived
+	a.TotalSent -= activity.TotalSent
+	a.TotalFeesPaid -= activity.TotalFeesPaid
+}
+
+func NewIncomingActivity(amount int64) *AccountActivity {
+	return &AccountActivity{
+		IncomingTxCount: 1,
+		TotalReceived:   amount,
+	}
+}
+
+func NewOutgoingActivity(amount int64, fee int64) *AccountActivity {
+	return &AccountActivity{
+		OutgoingTxCount: 1,
+		TotalSent:       amount,
+		TotalFeesPaid:   fee,
+	}
+}
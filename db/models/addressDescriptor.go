@@ -0,0 +1,47 @@
+package models
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+	"github.com/theQRL/qrl-rich-list-indexer/xmss"
+)
+
+type AddressDescriptor struct {
+	HashFunction   xmss.HashFunction   `json:"hashFunction" bson:"hashFunction"`
+	SignatureType  xmss.SignatureType  `json:"signatureType" bson:"signatureType"`
+	TreeHeight     uint8               `json:"treeHeight" bson:"treeHeight"`
+	AddrFormatType xmss.AddrFormatType `json:"addrFormatType" bson:"addrFormatType"`
+}
+
+// NewAddressDescriptor decodes the descriptor from the leading bytes of an
+// address. It returns nil for addresses which are not XMSS addresses.
+func NewAddressDescriptor(address common.Address) *AddressDescriptor {
+	byteAddress, err := misc.ToByteAddress(address)
+	if err != nil {
+		return nil
+	}
+	desc := xmss.NewQRLDescriptorFromBytes(byteAddress[:xmss.DescriptorSize])
+	if desc.GetSignatureType() != xmss.XMSSSig {
+		return nil
+	}
+
+	return &AddressDescriptor{
+		HashFunction:   desc.GetHashFunction(),
+		SignatureType:  desc.GetSignatureType(),
+		TreeHeight:     desc.GetHeight(),
+		AddrFormatType: desc.GetAddrFormatType(),
+	}
+}
+
+// AddressTypeFilter restricts a rich list to the addresses matching every non nil field
+type AddressTypeFilter struct {
+	HashFunction *xmss.HashFunction
+	TreeHeight   *uint8
+}
+
+// AddressTypeStats aggregates the accounts sharing a hash function or tree height
+type AddressTypeStats struct {
+	Value        int64 `json:"value" bson:"_id"`
+	HolderCount  int64 `json:"holderCount" bson:"holderCount"`
+	TotalBalance int64 `json:"totalBalance" bson:"totalBalance"`
+}
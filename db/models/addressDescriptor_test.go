@@ -0,0 +1,61 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+	"github.com/theQRL/qrl-rich-list-indexer/xmss"
+)
+
+// newTestXMSSAddress returns the address of an extended public key with the given descriptor
+func newTestXMSSAddress(descriptor ...byte) common.Address {
+	ePK := make([]byte, xmss.ExtendedPKSize)
+	copy(ePK, descriptor)
+	return xmss.GetXMSSAddressFromPK(ePK)
+}
+
+func TestNewAddressDescriptor(t *testing.T) {
+	tests := []struct {
+		name    string
+		address common.Address
+		want    *AddressDescriptor
+	}{
+		{
+			name:    "sha2_256 tree height 10",
+			address: newTestXMSSAddress(0x00, 0x05, 0x00),
+			want:    &AddressDescriptor{HashFunction: xmss.SHA2_256, SignatureType: xmss.XMSSSig, TreeHeight: 10},
+		},
+		{
+			name:    "shake_128 tree height 18",
+			address: newTestXMSSAddress(0x01, 0x09, 0x00),
+			want:    &AddressDescriptor{HashFunction: xmss.SHAKE_128, SignatureType: xmss.XMSSSig, TreeHeight: 18},
+		},
+		{
+			name:    "shake_256 tree height 4",
+			address: newTestXMSSAddress(0x02, 0x02, 0x00),
+			want:    &AddressDescriptor{HashFunction: xmss.SHAKE_256, SignatureType: xmss.XMSSSig, TreeHeight: 4},
+		},
+		{
+			name:    "multisig address",
+			address: misc.GetMultiSigAddress([]byte{0x01, 0x02}),
+		},
+		{
+			name:    "not hex",
+			address: common.Address("Qzz"),
+		},
+		{
+			name:    "missing prefix",
+			address: common.Address("0105"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewAddressDescriptor(tt.address)
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("NewAddressDescriptor(%s) = %+v, want %+v", tt.address, got, tt.want)
+			}
+		})
+	}
+}
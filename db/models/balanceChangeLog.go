@@ -5,15 +5,24 @@ import (
 )
 
 type BalanceChangeLog struct {
-	BlockNumber int64          `json:"blockNumber" bson:"blockNumber"`
-	Address     common.Address `json:"from" bson:"from"`
-	DeltaAmount int64          `json:"deltaAmount" bson:"deltaAmount"` // Change in amount it will be positive if amount increased and negative if amount decreased
+	BlockNumber  int64          `json:"blockNumber" bson:"blockNumber"`
+	Address      common.Address `json:"from" bson:"from"`
+	DeltaAmount  int64          `json:"deltaAmount" bson:"deltaAmount"`   // Change in amount it will be positive if amount increased and negative if amount decreased
+	BalanceAfter int64          `json:"balanceAfter" bson:"balanceAfter"` // Balance of the address at the end of the block
+
+	PrevLastActiveBlockNumber int64           `json:"prevLastActiveBlockNumber" bson:"prevLastActiveBlockNumber"`
+	PrevLastActiveTimestamp   uint64          `json:"prevLastActiveTimestamp" bson:"prevLastActiveTimestamp"`
+	Activity                  AccountActivity `json:"activity" bson:"activity"` // Change in account activity within the block
 }
 
 func (b *BalanceChangeLog) UpdateDeltaAmount(deltaAmount int64) {
 	b.DeltaAmount += deltaAmount
 }
 
+func (b *BalanceChangeLog) UpdateActivity(activity *AccountActivity) {
+	b.Activity.Add(activity)
+}
+
 func NewBalanceChangeLog(blockNumber int64, address common.Address) *BalanceChangeLog {
 	return &BalanceChangeLog{
 		BlockNumber: blockNumber,
@@ -7,17 +7,47 @@ import (
 )
 
 type Block struct {
-	Number int64       `json:"number" bson:"number"`
-	Hash   common.Hash `json:"hash" bson:"hash"`
+	Number   int64       `json:"number" bson:"number"`
+	Hash     common.Hash `json:"hash" bson:"hash"`
+	PrevHash common.Hash `json:"prevHash" bson:"prevHash"`
+
+	Timestamp     uint64           `json:"timestamp" bson:"timestamp"`
+	BlockReward   int64            `json:"blockReward" bson:"blockReward"`
+	FeeReward     int64            `json:"feeReward" bson:"feeReward"`
+	Miner         common.Address   `json:"miner,omitempty" bson:"miner,omitempty"`
+	TxCountByType map[string]int64 `json:"txCountByType" bson:"txCountByType"`
 }
 
 func NewBlockFromPBData(pbBlock *generated.Block) *Block {
-	return &Block{
-		Number: int64(pbBlock.Header.BlockNumber),
-		Hash:   misc.ToSizedHash(pbBlock.Header.HashHeader),
+	b := &Block{
+		Number:        int64(pbBlock.Header.BlockNumber),
+		Hash:          misc.ToSizedHash(pbBlock.Header.HashHeader),
+		PrevHash:      misc.ToSizedHash(pbBlock.Header.HashHeaderPrev),
+		Timestamp:     pbBlock.Header.TimestampSeconds,
+		BlockReward:   int64(pbBlock.Header.RewardBlock),
+		FeeReward:     int64(pbBlock.Header.RewardFee),
+		TxCountByType: make(map[string]int64),
+	}
+
+	for _, pbTX := range pbBlock.Transactions {
+		b.TxCountByType[GetTransactionType(pbTX)]++
+		if coinBaseTX := pbTX.GetCoinbase(); coinBaseTX != nil {
+			b.Miner = misc.ToStringAddress(coinBaseTX.AddrTo)
+		}
 	}
+
+	return b
 }
 
 func (b *Block) GetNumber() uint64 {
 	return uint64(b.Number)
 }
+
+// GetTxCount returns the total number of transactions in the block
+func (b *Block) GetTxCount() int64 {
+	txCount := int64(0)
+	for _, count := range b.TxCountByType {
+		txCount += count
+	}
+	return txCount
+}
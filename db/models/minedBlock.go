@@ -0,0 +1,22 @@
+package models
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+)
+
+type MinedBlock struct {
+	BlockNumber int64          `json:"blockNumber" bson:"blockNumber"`
+	Miner       common.Address `json:"miner" bson:"miner"`
+	BlockReward int64          `json:"blockReward" bson:"blockReward"`
+	FeeReward   int64          `json:"feeReward" bson:"feeReward"`
+}
+
+func NewMinedBlockFromPBData(pbBlock *generated.Block, miner common.Address) *MinedBlock {
+	return &MinedBlock{
+		BlockNumber: int64(pbBlock.Header.BlockNumber),
+		Miner:       miner,
+		BlockReward: int64(pbBlock.Header.RewardBlock),
+		FeeReward:   int64(pbBlock.Header.RewardFee),
+	}
+}
@@ -0,0 +1,36 @@
+package models
+
+import "github.com/theQRL/qrl-rich-list-indexer/common"
+
+type Miner struct {
+	Address          common.Address `json:"address" bson:"address"`
+	BlocksMined      int64          `json:"blocksMined" bson:"blocksMined"`
+	TotalBlockReward int64          `json:"totalBlockReward" bson:"totalBlockReward"`
+	TotalFeeReward   int64          `json:"totalFeeReward" bson:"totalFeeReward"`
+}
+
+func (m *Miner) AddMinedBlock(minedBlock *MinedBlock) {
+	m.BlocksMined++
+	m.TotalBlockReward += minedBlock.BlockReward
+	m.TotalFeeReward += minedBlock.FeeReward
+}
+
+func (m *Miner) RevertMinedBlock(minedBlock *MinedBlock) {
+	m.BlocksMined--
+	m.TotalBlockReward -= minedBlock.BlockReward
+	m.TotalFeeReward -= minedBlock.FeeReward
+}
+
+func NewMiner(address common.Address) *Miner {
+	return &Miner{
+		Address: address,
+	}
+}
+
+// MinerShare is the share of blocks mined by a miner within a window of
+// the most recent blocks
+type MinerShare struct {
+	Address     common.Address `json:"address" bson:"_id"`
+	BlocksMined int64          `json:"blocksMined" bson:"blocksMined"`
+	Share       float64        `json:"share" bson:"-"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+)
+
+type MultiSigAddress struct {
+	Address     common.Address   `json:"address" bson:"address"`
+	Creator     common.Address   `json:"creator" bson:"creator"`
+	Signatories []common.Address `json:"signatories" bson:"signatories"`
+	Weights     []uint32         `json:"weights" bson:"weights"`
+	Threshold   uint32           `json:"threshold" bson:"threshold"`
+	TxHash      common.Hash      `json:"txHash" bson:"txHash"`
+	BlockNumber int64            `json:"blockNumber" bson:"blockNumber"`
+}
+
+func NewMultiSigAddressFromPBData(blockNumber int64, creator common.Address, pbTX *generated.Transaction) *MultiSigAddress {
+	multiSigCreateTX := pbTX.GetMultiSigCreate()
+	signatories := make([]common.Address, len(multiSigCreateTX.Signatories))
+	for i, signatory := range multiSigCreateTX.Signatories {
+		signatories[i] = misc.ToStringAddress(signatory)
+	}
+
+	return &MultiSigAddress{
+		Address:     misc.GetMultiSigAddress(pbTX.TransactionHash),
+		Creator:     creator,
+		Signatories: signatories,
+		Weights:     multiSigCreateTX.Weights,
+		Threshold:   multiSigCreateTX.Threshold,
+		TxHash:      misc.ToSizedHash(pbTX.TransactionHash),
+		BlockNumber: blockNumber,
+	}
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+)
+
+// MultiSigSpend holds the vote state of a MultiSigSpend transaction, keyed by
+// its transaction hash which MultiSigVote transactions refer to as shared key
+type MultiSigSpend struct {
+	SharedKey           common.Hash      `json:"sharedKey" bson:"sharedKey"`
+	MultiSigAddress     common.Address   `json:"multiSigAddress" bson:"multiSigAddress"`
+	Creator             common.Address   `json:"creator" bson:"creator"`
+	AddrsTo             []common.Address `json:"addrsTo" bson:"addrsTo"`
+	Amounts             []int64          `json:"amounts" bson:"amounts"`
+	ExpiryBlockNumber   int64            `json:"expiryBlockNumber" bson:"expiryBlockNumber"`
+	BlockNumber         int64            `json:"blockNumber" bson:"blockNumber"`
+	Signatories         []common.Address `json:"signatories" bson:"signatories"`
+	Weights             []uint32         `json:"weights" bson:"weights"`
+	Threshold           uint32           `json:"threshold" bson:"threshold"`
+	Unvotes             []bool           `json:"unvotes" bson:"unvotes"`
+	TotalWeight         int64            `json:"totalWeight" bson:"totalWeight"`
+	Executed            bool             `json:"executed" bson:"executed"`
+	ExecutedBlockNumber int64            `json:"executedBlockNumber" bson:"executedBlockNumber"`
+}
+
+func (s *MultiSigSpend) getSignatoryIndex(address common.Address) int {
+	for i, signatory := range s.Signatories {
+		if signatory == address {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MultiSigSpend) TotalAmount() int64 {
+	totalAmount := int64(0)
+	for _, amount := range s.Amounts {
+		totalAmount += amount
+	}
+	return totalAmount
+}
+
+// ApplyVote applies a vote or unvote by voter at blockNumber. It returns the
+// weight of the voter and false if the vote had no effect on the vote state.
+func (s *MultiSigSpend) ApplyVote(blockNumber int64, voter common.Address, unvote bool) (uint32, bool) {
+	if blockNumber > s.ExpiryBlockNumber {
+		return 0, false
+	}
+	i := s.getSignatoryIndex(voter)
+	if i == -1 || s.Unvotes[i] == unvote {
+		return 0, false
+	}
+	if unvote {
+		s.TotalWeight -= int64(s.Weights[i])
+	} else {
+		s.TotalWeight += int64(s.Weights[i])
+	}
+	s.Unvotes[i] = unvote
+	return s.Weights[i], true
+}
+
+// RevertVote undoes a vote previously applied by ApplyVote
+func (s *MultiSigSpend) RevertVote(voter common.Address, unvote bool, weight uint32) {
+	i := s.getSignatoryIndex(voter)
+	if i == -1 {
+		return
+	}
+	if unvote {
+		s.TotalWeight += int64(weight)
+	} else {
+		s.TotalWeight -= int64(weight)
+	}
+	s.Unvotes[i] = !unvote
+}
+
+// IsExecutable returns true if the spend has not been executed yet and the
+// votes have reached the threshold before expiry
+func (s *MultiSigSpend) IsExecutable(blockNumber int64) bool {
+	return !s.Executed && blockNumber <= s.ExpiryBlockNumber && s.TotalWeight >= int64(s.Threshold)
+}
+
+func (s *MultiSigSpend) SetExecuted(blockNumber int64) {
+	s.Executed = true
+	s.ExecutedBlockNumber = blockNumber
+}
+
+func (s *MultiSigSpend) ResetExecuted() {
+	s.Executed = false
+	s.ExecutedBlockNumber = 0
+}
+
+func NewMultiSigSpendFromPBData(blockNumber int64, creator common.Address,
+	multiSigAddress *MultiSigAddress, pbTX *generated.Transaction) *MultiSigSpend {
+	multiSigSpendTX := pbTX.GetMultiSigSpend()
+	addrsTo := make([]common.Address, len(multiSigSpendTX.AddrsTo))
+	amounts := make([]int64, len(multiSigSpendTX.AddrsTo))
+	for i, addr := range multiSigSpendTX.AddrsTo {
+		addrsTo[i] = misc.ToStringAddress(addr)
+		amounts[i] = int64(multiSigSpendTX.Amounts[i])
+	}
+
+	// Initially none of the signatories has voted
+	unvotes := make([]bool, len(multiSigAddress.Signatories))
+	for i := range unvotes {
+		unvotes[i] = true
+	}
+
+	return &MultiSigSpend{
+		SharedKey:         misc.ToSizedHash(pbTX.TransactionHash),
+		MultiSigAddress:   multiSigAddress.Address,
+		Creator:           creator,
+		AddrsTo:           addrsTo,
+		Amounts:           amounts,
+		ExpiryBlockNumber: int64(multiSigSpendTX.ExpiryBlockNumber),
+		BlockNumber:       blockNumber,
+		Signatories:       multiSigAddress.Signatories,
+		Weights:           multiSigAddress.Weights,
+		Threshold:         multiSigAddress.Threshold,
+		Unvotes:           unvotes,
+	}
+}
@@ -0,0 +1,246 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+)
+
+const (
+	signatoryA = common.Address("Qa")
+	signatoryB = common.Address("Qb")
+	signatoryC = common.Address("Qc")
+	outsider   = common.Address("Qz")
+)
+
+func newTestMultiSigSpend() *MultiSigSpend {
+	return &MultiSigSpend{
+		MultiSigAddress:   common.Address("Qmultisig"),
+		AddrsTo:           []common.Address{outsider},
+		Amounts:           []int64{100},
+		ExpiryBlockNumber: 10,
+		BlockNumber:       1,
+		Signatories:       []common.Address{signatoryA, signatoryB, signatoryC},
+		Weights:           []uint32{2, 3, 5},
+		Threshold:         5,
+		Unvotes:           []bool{true, true, true},
+	}
+}
+
+type testVote struct {
+	blockNumber int64
+	voter       common.Address
+	unvote      bool
+
+	wantWeight  uint32
+	wantApplied bool
+}
+
+func TestMultiSigSpendApplyVote(t *testing.T) {
+	tests := []struct {
+		name            string
+		votes           []testVote
+		wantTotalWeight int64
+		wantExecutable  bool
+	}{
+		{
+			name: "vote",
+			votes: []testVote{
+				{blockNumber: 2, voter: signatoryA, wantWeight: 2, wantApplied: true},
+			},
+			wantTotalWeight: 2,
+		},
+		{
+			name: "vote reaching threshold",
+			votes: []testVote{
+				{blockNumber: 2, voter: signatoryA, wantWeight: 2, wantApplied: true},
+				{blockNumber: 3, voter: signatoryB, wantWeight: 3, wantApplied: true},
+			},
+			wantTotalWeight: 5,
+			wantExecutable:  true,
+		},
+		{
+			name: "unvote",
+			votes: []testVote{
+				{blockNumber: 2, voter: signatoryC, wantWeight: 5, wantApplied: true},
+				{blockNumber: 3, voter: signatoryC, unvote: true, wantWeight: 5, wantApplied: true},
+			},
+			wantTotalWeight: 0,
+		},
+		{
+			name: "unvote without vote",
+			votes: []testVote{
+				{blockNumber: 2, voter: signatoryA, unvote: true},
+			},
+			wantTotalWeight: 0,
+		},
+		{
+			name: "duplicate vote",
+			votes: []testVote{
+				{blockNumber: 2, voter: signatoryA, wantWeight: 2, wantApplied: true},
+				{blockNumber: 3, voter: signatoryA},
+			},
+			wantTotalWeight: 2,
+		},
+		{
+			name: "vote by non signatory",
+			votes: []testVote{
+				{blockNumber: 2, voter: outsider},
+			},
+			wantTotalWeight: 0,
+		},
+		{
+			name: "vote at expiry",
+			votes: []testVote{
+				{blockNumber: 10, voter: signatoryC, wantWeight: 5, wantApplied: true},
+			},
+			wantTotalWeight: 5,
+			wantExecutable:  true,
+		},
+		{
+			name: "vote reaching threshold after expiry",
+			votes: []testVote{
+				{blockNumber: 2, voter: signatoryA, wantWeight: 2, wantApplied: true},
+				{blockNumber: 11, voter: signatoryB},
+			},
+			wantTotalWeight: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestMultiSigSpend()
+			blockNumber := int64(0)
+			for _, v := range tt.votes {
+				weight, applied := s.ApplyVote(v.blockNumber, v.voter, v.unvote)
+				if weight != v.wantWeight || applied != v.wantApplied {
+					t.Fatalf("ApplyVote(%d, %s, %v) = (%d, %v), want (%d, %v)", v.blockNumber, v.voter, v.unvote,
+						weight, applied, v.wantWeight, v.wantApplied)
+				}
+				blockNumber = v.blockNumber
+			}
+			if s.TotalWeight != tt.wantTotalWeight {
+				t.Errorf("TotalWeight = %d, want %d", s.TotalWeight, tt.wantTotalWeight)
+			}
+			if got := s.IsExecutable(blockNumber); got != tt.wantExecutable {
+				t.Errorf("IsExecutable(%d) = %v, want %v", blockNumber, got, tt.wantExecutable)
+			}
+		})
+	}
+}
+
+func TestMultiSigSpendIsExecutable(t *testing.T) {
+	tests := []struct {
+		name        string
+		totalWeight int64
+		executed    bool
+		blockNumber int64
+		want        bool
+	}{
+		{name: "below threshold", totalWeight: 4, blockNumber: 5},
+		{name: "at threshold", totalWeight: 5, blockNumber: 5, want: true},
+		{name: "above threshold", totalWeight: 10, blockNumber: 5, want: true},
+		{name: "at threshold after expiry", totalWeight: 5, blockNumber: 11},
+		{name: "already executed", totalWeight: 5, executed: true, blockNumber: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestMultiSigSpend()
+			s.TotalWeight = tt.totalWeight
+			s.Executed = tt.executed
+			if got := s.IsExecutable(tt.blockNumber); got != tt.want {
+				t.Errorf("IsExecutable(%d) = %v, want %v", tt.blockNumber, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiSigSpendRevertVote(t *testing.T) {
+	tests := []struct {
+		name    string
+		votes   []testVote
+		revert  testVote
+		execute bool
+
+		wantTotalWeight int64
+		wantUnvotes     []bool
+		wantExecutable  bool
+	}{
+		{
+			name:            "revert vote",
+			votes:           []testVote{{blockNumber: 2, voter: signatoryA}},
+			revert:          testVote{voter: signatoryA, wantWeight: 2},
+			wantTotalWeight: 0,
+			wantUnvotes:     []bool{true, true, true},
+		},
+		{
+			name: "revert unvote",
+			votes: []testVote{
+				{blockNumber: 2, voter: signatoryB},
+				{blockNumber: 3, voter: signatoryB, unvote: true},
+			},
+			revert:          testVote{voter: signatoryB, unvote: true, wantWeight: 3},
+			wantTotalWeight: 3,
+			wantUnvotes:     []bool{true, false, true},
+		},
+		{
+			name: "revert after execution",
+			votes: []testVote{
+				{blockNumber: 2, voter: signatoryA},
+				{blockNumber: 3, voter: signatoryB},
+			},
+			revert:          testVote{voter: signatoryB, wantWeight: 3},
+			execute:         true,
+			wantTotalWeight: 2,
+			wantUnvotes:     []bool{false, true, true},
+		},
+		{
+			name: "revert vote below threshold after execution",
+			votes: []testVote{
+				{blockNumber: 2, voter: signatoryA},
+				{blockNumber: 3, voter: signatoryB},
+				{blockNumber: 4, voter: signatoryC},
+			},
+			revert:          testVote{voter: signatoryC, wantWeight: 5},
+			execute:         true,
+			wantTotalWeight: 5,
+			wantUnvotes:     []bool{false, false, true},
+			wantExecutable:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestMultiSigSpend()
+			for _, v := range tt.votes {
+				s.ApplyVote(v.blockNumber, v.voter, v.unvote)
+			}
+			if tt.execute {
+				if !s.IsExecutable(5) {
+					t.Fatal("IsExecutable(5) = false before revert")
+				}
+				s.SetExecuted(5)
+				// Reverting the executing block resets the execution before its votes
+				s.ResetExecuted()
+			}
+
+			s.RevertVote(tt.revert.voter, tt.revert.unvote, tt.revert.wantWeight)
+
+			if s.TotalWeight != tt.wantTotalWeight {
+				t.Errorf("TotalWeight = %d, want %d", s.TotalWeight, tt.wantTotalWeight)
+			}
+			for i, unvote := range tt.wantUnvotes {
+				if s.Unvotes[i] != unvote {
+					t.Errorf("Unvotes[%d] = %v, want %v", i, s.Unvotes[i], unvote)
+				}
+			}
+			if s.Executed || s.ExecutedBlockNumber != 0 {
+				t.Errorf("Executed = %v at %d, want not executed", s.Executed, s.ExecutedBlockNumber)
+			}
+			if got := s.IsExecutable(5); got != tt.wantExecutable {
+				t.Errorf("IsExecutable(5) = %v, want %v", got, tt.wantExecutable)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+)
+
+// MultiSigVote records a vote which changed the vote state of a MultiSigSpend,
+// so that it can be reverted
+type MultiSigVote struct {
+	SharedKey   common.Hash    `json:"sharedKey" bson:"sharedKey"`
+	Voter       common.Address `json:"voter" bson:"voter"`
+	Unvote      bool           `json:"unvote" bson:"unvote"`
+	Weight      uint32         `json:"weight" bson:"weight"`
+	TxHash      common.Hash    `json:"txHash" bson:"txHash"`
+	BlockNumber int64          `json:"blockNumber" bson:"blockNumber"`
+}
+
+func NewMultiSigVote(blockNumber int64, txHash []byte, sharedKey common.Hash,
+	voter common.Address, unvote bool, weight uint32) *MultiSigVote {
+	return &MultiSigVote{
+		SharedKey:   sharedKey,
+		Voter:       voter,
+		Unvote:      unvote,
+		Weight:      weight,
+		TxHash:      misc.ToSizedHash(txHash),
+		BlockNumber: blockNumber,
+	}
+}
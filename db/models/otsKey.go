@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/binary"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+)
+
+// GetOTSIndexFromSignature returns the OTS index encoded in the leading bytes
+// of an XMSS signature
+func GetOTSIndexFromSignature(signature []byte) (uint64, bool) {
+	if len(signature) < 4 {
+		return 0, false
+	}
+	return uint64(binary.BigEndian.Uint32(signature[:4])), true
+}
+
+type OTSKeyUsage struct {
+	Address     common.Address `json:"address" bson:"address"`
+	OTSIndex    uint64         `json:"otsIndex" bson:"otsIndex"`
+	Reused      bool           `json:"reused" bson:"reused"`
+	TxHash      common.Hash    `json:"txHash" bson:"txHash"`
+	BlockNumber int64          `json:"blockNumber" bson:"blockNumber"`
+}
+
+func NewOTSKeyUsage(blockNumber int64, txHash []byte, address common.Address, otsIndex uint64, reused bool) *OTSKeyUsage {
+	return &OTSKeyUsage{
+		Address:     address,
+		OTSIndex:    otsIndex,
+		Reused:      reused,
+		TxHash:      misc.ToSizedHash(txHash),
+		BlockNumber: blockNumber,
+	}
+}
+
+type OTSKeyStatus struct {
+	Address          common.Address `json:"address" bson:"address"`
+	TreeHeight       uint8          `json:"treeHeight" bson:"treeHeight"`
+	Capacity         int64          `json:"capacity" bson:"capacity"`
+	UsedCount        int64          `json:"usedCount" bson:"usedCount"`
+	ReuseCount       int64          `json:"reuseCount" bson:"reuseCount"`
+	Remaining        int64          `json:"remaining" bson:"remaining"`
+	IsReused         bool           `json:"isReused" bson:"isReused"`
+	IsNearExhaustion bool           `json:"isNearExhaustion" bson:"isNearExhaustion"`
+}
+
+func (o *OTSKeyStatus) updateFlags(warningRemainingPercentage int64) {
+	o.Remaining = o.Capacity - o.UsedCount
+	o.IsReused = o.ReuseCount > 0
+	o.IsNearExhaustion = o.Remaining*100 <= o.Capacity*warningRemainingPercentage
+}
+
+func (o *OTSKeyStatus) AddUsage(otsKeyUsage *OTSKeyUsage, warningRemainingPercentage int64) {
+	if otsKeyUsage.Reused {
+		o.ReuseCount++
+	} else {
+		o.UsedCount++
+	}
+	o.updateFlags(warningRemainingPercentage)
+}
+
+func (o *OTSKeyStatus) RevertUsage(otsKeyUsage *OTSKeyUsage, warningRemainingPercentage int64) {
+	if otsKeyUsage.Reused {
+		o.ReuseCount--
+	} else {
+		o.UsedCount--
+	}
+	o.updateFlags(warningRemainingPercentage)
+}
+
+func NewOTSKeyStatus(address common.Address, treeHeight uint8) *OTSKeyStatus {
+	capacity := int64(1) << treeHeight
+	return &OTSKeyStatus{
+		Address:    address,
+		TreeHeight: treeHeight,
+		Capacity:   capacity,
+		Remaining:  capacity,
+	}
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+)
+
+func TestGetOTSIndexFromSignature(t *testing.T) {
+	tests := []struct {
+		name      string
+		signature []byte
+		wantIndex uint64
+		wantOK    bool
+	}{
+		{name: "empty"},
+		{name: "too short", signature: []byte{0x00, 0x00, 0x01}},
+		{name: "first key", signature: []byte{0x00, 0x00, 0x00, 0x00, 0xff}, wantIndex: 0, wantOK: true},
+		{name: "big endian", signature: []byte{0x00, 0x00, 0x01, 0x02, 0xff}, wantIndex: 258, wantOK: true},
+		{name: "highest index", signature: []byte{0xff, 0xff, 0xff, 0xff}, wantIndex: 1<<32 - 1, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			index, ok := GetOTSIndexFromSignature(tt.signature)
+			if index != tt.wantIndex || ok != tt.wantOK {
+				t.Errorf("GetOTSIndexFromSignature() = (%d, %v), want (%d, %v)", index, ok, tt.wantIndex, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestOTSKeyStatusAddAndRevertUsage(t *testing.T) {
+	const warningRemainingPercentage = 50
+	address := common.Address("Qa")
+	usages := []*OTSKeyUsage{
+		NewOTSKeyUsage(1, []byte{0x01}, address, 0, false),
+		NewOTSKeyUsage(2, []byte{0x02}, address, 1, false),
+		NewOTSKeyUsage(3, []byte{0x03}, address, 1, true),
+	}
+	wantStatuses := []OTSKeyStatus{
+		{Address: address, TreeHeight: 2, Capacity: 4, UsedCount: 1, Remaining: 3},
+		{Address: address, TreeHeight: 2, Capacity: 4, UsedCount: 2, Remaining: 2, IsNearExhaustion: true},
+		{Address: address, TreeHeight: 2, Capacity: 4, UsedCount: 2, ReuseCount: 1, Remaining: 2,
+			IsReused: true, IsNearExhaustion: true},
+	}
+
+	o := NewOTSKeyStatus(address, 2)
+	for i, otsKeyUsage := range usages {
+		o.AddUsage(otsKeyUsage, warningRemainingPercentage)
+		if *o != wantStatuses[i] {
+			t.Errorf("after AddUsage() #%d status = %+v, want %+v", i, *o, wantStatuses[i])
+		}
+	}
+
+	for i := len(usages) - 1; i >= 0; i-- {
+		o.RevertUsage(usages[i], warningRemainingPercentage)
+		want := *NewOTSKeyStatus(address, 2)
+		if i > 0 {
+			want = wantStatuses[i-1]
+		}
+		if *o != want {
+			t.Errorf("after RevertUsage() #%d status = %+v, want %+v", i, *o, want)
+		}
+	}
+}
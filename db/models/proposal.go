@@ -0,0 +1,92 @@
+package models
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+)
+
+const (
+	ProposalTypeQIP    = "QIP"
+	ProposalTypeConfig = "Config"
+	ProposalTypeOther  = "Other"
+)
+
+type ProposalOptionTally struct {
+	Option string `json:"option" bson:"option"`
+	Weight int64  `json:"weight" bson:"weight"` // Sum of the indexed balances of the voters
+	Voters int64  `json:"voters" bson:"voters"`
+}
+
+type Proposal struct {
+	SharedKey         common.Hash    `json:"sharedKey" bson:"sharedKey"`
+	Creator           common.Address `json:"creator" bson:"creator"`
+	Description       string         `json:"description" bson:"description"`
+	Type              string         `json:"type" bson:"type"`
+	QIPLink           string         `json:"qipLink,omitempty" bson:"qipLink,omitempty"`
+	Options           []string       `json:"options" bson:"options"`
+	ExpiryBlockNumber int64          `json:"expiryBlockNumber" bson:"expiryBlockNumber"`
+	BlockNumber       int64          `json:"blockNumber" bson:"blockNumber"`
+
+	// Tally is final once the proposal has expired, and is weighted by the
+	// balances of the voters at the expiry block
+	Tally                []*ProposalOptionTally `json:"tally" bson:"tally"`
+	Finalized            bool                   `json:"finalized" bson:"finalized"`
+	FinalizedBlockNumber int64                  `json:"finalizedBlockNumber" bson:"finalizedBlockNumber"`
+}
+
+func (p *Proposal) IsValidOption(option uint32) bool {
+	return int(option) < len(p.Options)
+}
+
+// NewTally returns an empty tally with an entry for every option
+func (p *Proposal) NewTally() []*ProposalOptionTally {
+	tally := make([]*ProposalOptionTally, len(p.Options))
+	for i, option := range p.Options {
+		tally[i] = &ProposalOptionTally{Option: option}
+	}
+	return tally
+}
+
+func (p *Proposal) SetFinalized(blockNumber int64, tally []*ProposalOptionTally) {
+	p.Tally = tally
+	p.Finalized = true
+	p.FinalizedBlockNumber = blockNumber
+}
+
+func (p *Proposal) ResetFinalized() {
+	p.Tally = nil
+	p.Finalized = false
+	p.FinalizedBlockNumber = 0
+}
+
+func NewProposalFromPBData(blockNumber int64, creator common.Address, defaultOptions []string,
+	pbTX *generated.Transaction) *Proposal {
+	proposalCreateTX := pbTX.GetProposalCreate()
+	p := &Proposal{
+		SharedKey:         misc.ToSizedHash(pbTX.TransactionHash),
+		Creator:           creator,
+		Description:       proposalCreateTX.Description,
+		Options:           append([]string{}, defaultOptions...),
+		ExpiryBlockNumber: int64(proposalCreateTX.ExpiryBlockNumber),
+		BlockNumber:       blockNumber,
+	}
+	// A proposal expiring before its creation block is finalized right away,
+	// as it would never be seen again by the expiry pass otherwise
+	if p.ExpiryBlockNumber < blockNumber {
+		p.ExpiryBlockNumber = blockNumber
+	}
+
+	switch proposalCreateTX.ProposalType.(type) {
+	case *generated.Transaction_ProposalCreate_Qip:
+		p.Type = ProposalTypeQIP
+		p.QIPLink = proposalCreateTX.GetQip().QipLink
+	case *generated.Transaction_ProposalCreate_Config_:
+		p.Type = ProposalTypeConfig
+	case *generated.Transaction_ProposalCreate_Other_:
+		p.Type = ProposalTypeOther
+		p.Options = append(p.Options, proposalCreateTX.GetOther().Options...)
+	}
+
+	return p
+}
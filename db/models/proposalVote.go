@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+)
+
+type ProposalVote struct {
+	SharedKey   common.Hash    `json:"sharedKey" bson:"sharedKey"`
+	Voter       common.Address `json:"voter" bson:"voter"`
+	Option      uint32         `json:"option" bson:"option"`
+	TxHash      common.Hash    `json:"txHash" bson:"txHash"`
+	BlockNumber int64          `json:"blockNumber" bson:"blockNumber"`
+}
+
+func NewProposalVote(blockNumber int64, txHash []byte, sharedKey common.Hash,
+	voter common.Address, option uint32) *ProposalVote {
+	return &ProposalVote{
+		SharedKey:   sharedKey,
+		Voter:       voter,
+		Option:      option,
+		TxHash:      misc.ToSizedHash(txHash),
+		BlockNumber: blockNumber,
+	}
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+)
+
+// RichListSnapshotDayLayout is the layout of the UTC day a daily snapshot was taken for
+const RichListSnapshotDayLayout = "2006-01-02"
+
+type RichListEntry struct {
+	Rank    int64          `json:"rank" bson:"rank"`
+	Address common.Address `json:"address" bson:"address"`
+	Balance int64          `json:"balance" bson:"balance"`
+}
+
+// RichListSnapshot is the ranked holder list at the end of a block. Day is
+// only set for the snapshot taken at the first block of a UTC day.
+type RichListSnapshot struct {
+	BlockNumber int64            `json:"blockNumber" bson:"blockNumber"`
+	Timestamp   uint64           `json:"timestamp" bson:"timestamp"`
+	Day         string           `json:"day,omitempty" bson:"day,omitempty"`
+	Holders     []*RichListEntry `json:"holders" bson:"holders"`
+}
+
+// GetRichListSnapshotDay returns the UTC day of timestamp in RichListSnapshotDayLayout
+func GetRichListSnapshotDay(timestamp uint64) string {
+	return time.Unix(int64(timestamp), 0).UTC().Format(RichListSnapshotDayLayout)
+}
+
+func NewRichListSnapshot(blockNumber int64, timestamp uint64, day string, accounts []*Account) *RichListSnapshot {
+	holders := make([]*RichListEntry, len(accounts))
+	for i, a := range accounts {
+		holders[i] = &RichListEntry{
+			Rank:    int64(i + 1),
+			Address: a.Address,
+			Balance: a.Balance,
+		}
+	}
+
+	return &RichListSnapshot{
+		BlockNumber: blockNumber,
+		Timestamp:   timestamp,
+		Day:         day,
+		Holders:     holders,
+	}
+}
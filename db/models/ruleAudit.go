@@ -0,0 +1,40 @@
+package models
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/rules"
+)
+
+// RuleAudit records the amount by which a rule changed the indexed balance of an address
+type RuleAudit struct {
+	BlockNumber     int64          `json:"blockNumber" bson:"blockNumber"`
+	Address         common.Address `json:"address" bson:"address"`
+	Action          rules.Action   `json:"action" bson:"action"`
+	Amount          int64          `json:"amount" bson:"amount"`
+	Reason          string         `json:"reason" bson:"reason"`
+	RuleStartHeight uint64         `json:"ruleStartHeight" bson:"ruleStartHeight"`
+}
+
+func NewRuleAudit(blockNumber int64, rule *rules.Rule, amount int64) *RuleAudit {
+	return &RuleAudit{
+		BlockNumber:     blockNumber,
+		Address:         rule.Address,
+		Action:          rule.Action,
+		Amount:          amount,
+		Reason:          rule.Reason,
+		RuleStartHeight: rule.StartHeight,
+	}
+}
+
+// RuleSet is the rule set the indexed data was built with
+type RuleSet struct {
+	Fingerprint string        `json:"fingerprint" bson:"fingerprint"`
+	Rules       []*rules.Rule `json:"rules" bson:"rules"`
+}
+
+func NewRuleSet(engine *rules.Engine) *RuleSet {
+	return &RuleSet{
+		Fingerprint: engine.Fingerprint(),
+		Rules:       engine.Rules(),
+	}
+}
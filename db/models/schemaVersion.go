@@ -0,0 +1,12 @@
+package models
+
+// SchemaVersion is the version of the last migration applied to the database
+type SchemaVersion struct {
+	Version int64 `json:"version" bson:"version"`
+}
+
+func NewSchemaVersion(version int64) *SchemaVersion {
+	return &SchemaVersion{
+		Version: version,
+	}
+}
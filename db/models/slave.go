@@ -0,0 +1,29 @@
+package models
+
+import (
+	"encoding/hex"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+	"github.com/theQRL/qrl-rich-list-indexer/xmss"
+)
+
+type Slave struct {
+	PublicKey     string         `json:"publicKey" bson:"publicKey"`
+	Address       common.Address `json:"address" bson:"address"`
+	MasterAddress common.Address `json:"masterAddress" bson:"masterAddress"`
+	AccessType    uint32         `json:"accessType" bson:"accessType"`
+	TxHash        common.Hash    `json:"txHash" bson:"txHash"`
+	BlockNumber   int64          `json:"blockNumber" bson:"blockNumber"`
+}
+
+func NewSlave(blockNumber int64, txHash []byte, masterAddress common.Address, publicKey []byte, accessType uint32) *Slave {
+	return &Slave{
+		PublicKey:     hex.EncodeToString(publicKey),
+		Address:       xmss.GetXMSSAddressFromPK(publicKey),
+		MasterAddress: masterAddress,
+		AccessType:    accessType,
+		TxHash:        misc.ToSizedHash(txHash),
+		BlockNumber:   blockNumber,
+	}
+}
@@ -1,11 +1,34 @@
 package models
 
+import "fmt"
+
+const (
+	StatsTotalSupply       = "totalSupply"
+	StatsNonZeroHolders    = "nonZeroHolders"
+	StatsLastIndexedHeight = "lastIndexedHeight"
+)
+
+// StatsHoldersAbove is the name of the stats holding the number of holders
+// with balance above threshold
+func StatsHoldersAbove(threshold int64) string {
+	return fmt.Sprintf("holdersAbove_%d", threshold)
+}
+
+// StatsTopBalance is the name of the stats holding the total balance of the
+// top count holders
+func StatsTopBalance(count int64) string {
+	return fmt.Sprintf("top%dBalance", count)
+}
+
 type Stats struct {
 	Name  string `json:"name" bson:"name"`
 	Value int64  `json:"value" bson:"value"`
 }
 
-// TODO (cyyber): Stats could be added in future
+func (s *Stats) UpdateValue(value int64) {
+	s.Value += value
+}
+
 func NewStats(name string, value int64) *Stats {
 	return &Stats{
 		Name:  name,
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+)
+
+type Token struct {
+	TxHash            common.Hash    `json:"txHash" bson:"txHash"`
+	Symbol            string         `json:"symbol" bson:"symbol"`
+	Name              string         `json:"name" bson:"name"`
+	Owner             common.Address `json:"owner" bson:"owner"`
+	Decimals          uint64         `json:"decimals" bson:"decimals"`
+	BlockNumber       int64          `json:"blockNumber" bson:"blockNumber"`
+	HolderCount       int64          `json:"holderCount" bson:"holderCount"`
+	CirculatingAmount int64          `json:"circulatingAmount" bson:"circulatingAmount"`
+}
+
+// UpdateFromHolderBalance adjusts holder count and circulating amount for
+// a token holder whose balance changed from prevBalance by deltaAmount
+func (t *Token) UpdateFromHolderBalance(prevBalance int64, deltaAmount int64) {
+	balance := prevBalance + deltaAmount
+	if prevBalance <= 0 && balance > 0 {
+		t.HolderCount++
+	} else if prevBalance > 0 && balance <= 0 {
+		t.HolderCount--
+	}
+	t.CirculatingAmount += deltaAmount
+}
+
+func NewTokenFromPBData(blockNumber int64, pbTX *generated.Transaction) *Token {
+	tokenTX := pbTX.GetToken()
+	return &Token{
+		TxHash:      misc.ToSizedHash(pbTX.TransactionHash),
+		Symbol:      string(tokenTX.Symbol),
+		Name:        string(tokenTX.Name),
+		Owner:       misc.ToStringAddress(tokenTX.Owner),
+		Decimals:    tokenTX.Decimals,
+		BlockNumber: blockNumber,
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+)
+
+type TokenBalanceChangeLog struct {
+	BlockNumber int64          `json:"blockNumber" bson:"blockNumber"`
+	TokenTxHash common.Hash    `json:"tokenTxHash" bson:"tokenTxHash"`
+	Address     common.Address `json:"address" bson:"address"`
+	DeltaAmount int64          `json:"deltaAmount" bson:"deltaAmount"` // Change in token amount, positive if increased and negative if decreased
+}
+
+func (t *TokenBalanceChangeLog) UpdateDeltaAmount(deltaAmount int64) {
+	t.DeltaAmount += deltaAmount
+}
+
+func NewTokenBalanceChangeLog(blockNumber int64, tokenTxHash common.Hash, address common.Address) *TokenBalanceChangeLog {
+	return &TokenBalanceChangeLog{
+		BlockNumber: blockNumber,
+		TokenTxHash: tokenTxHash,
+		Address:     address,
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import "github.com/theQRL/qrl-rich-list-indexer/common"
+
+type TokenHolder struct {
+	TokenTxHash common.Hash    `json:"tokenTxHash" bson:"tokenTxHash"`
+	Address     common.Address `json:"address" bson:"address"`
+	Balance     int64          `json:"balance" bson:"balance"`
+}
+
+func (t *TokenHolder) UpdateBalance(balance int64) {
+	t.Balance += balance
+}
+
+func NewTokenHolder(tokenTxHash common.Hash, address common.Address) *TokenHolder {
+	return &TokenHolder{
+		TokenTxHash: tokenTxHash,
+		Address:     address,
+		Balance:     0,
+	}
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/hex"
+	"unicode/utf8"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+	"github.com/theQRL/qrl-rich-list-indexer/xmss"
+)
+
+const (
+	TransactionTypeTransfer       = "transfer"
+	TransactionTypeCoinbase       = "coinbase"
+	TransactionTypeLatticePK      = "latticePK"
+	TransactionTypeMessage        = "message"
+	TransactionTypeToken          = "token"
+	TransactionTypeTransferToken  = "transferToken"
+	TransactionTypeSlave          = "slave"
+	TransactionTypeMultiSigCreate = "multiSigCreate"
+	TransactionTypeMultiSigSpend  = "multiSigSpend"
+	TransactionTypeMultiSigVote   = "multiSigVote"
+	TransactionTypeProposalCreate = "proposalCreate"
+	TransactionTypeProposalVote   = "proposalVote"
+	TransactionTypeUnknown        = "unknown"
+)
+
+type TransactionOutput struct {
+	Address common.Address `json:"address" bson:"address"`
+	Amount  int64          `json:"amount" bson:"amount"`
+}
+
+type Transaction struct {
+	Hash        common.Hash          `json:"hash" bson:"hash"`
+	BlockNumber int64                `json:"blockNumber" bson:"blockNumber"`
+	Timestamp   uint64               `json:"timestamp" bson:"timestamp"`
+	Type        string               `json:"type" bson:"type"`
+	From        common.Address       `json:"from,omitempty" bson:"from,omitempty"`
+	Signer      common.Address       `json:"signer,omitempty" bson:"signer,omitempty"`
+	Outputs     []*TransactionOutput `json:"outputs" bson:"outputs"`
+	TokenTxHash *common.Hash         `json:"tokenTxHash,omitempty" bson:"tokenTxHash,omitempty"` // Set if outputs are token amounts
+	Fee         int64                `json:"fee" bson:"fee"`
+	Memo        string               `json:"memo,omitempty" bson:"memo,omitempty"`
+
+	// Set for multi sig spends, which are paid by MultiSigAddress once the
+	// votes reach the threshold. Outputs are not paid while Pending.
+	MultiSigAddress common.Address `json:"multiSigAddress,omitempty" bson:"multiSigAddress,omitempty"`
+	Pending         bool           `json:"pending,omitempty" bson:"pending,omitempty"`
+}
+
+func (t *Transaction) addOutput(address []byte, amount uint64) {
+	t.Outputs = append(t.Outputs, &TransactionOutput{
+		Address: misc.ToStringAddress(address),
+		Amount:  int64(amount),
+	})
+}
+
+func toMemo(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return hex.EncodeToString(data)
+}
+
+func GetTransactionType(pbTX *generated.Transaction) string {
+	switch pbTX.TransactionType.(type) {
+	case *generated.Transaction_Transfer_:
+		return TransactionTypeTransfer
+	case *generated.Transaction_Coinbase:
+		return TransactionTypeCoinbase
+	case *generated.Transaction_LatticePK:
+		return TransactionTypeLatticePK
+	case *generated.Transaction_Message_:
+		return TransactionTypeMessage
+	case *generated.Transaction_Token_:
+		return TransactionTypeToken
+	case *generated.Transaction_TransferToken_:
+		return TransactionTypeTransferToken
+	case *generated.Transaction_Slave_:
+		return TransactionTypeSlave
+	case *generated.Transaction_MultiSigCreate_:
+		return TransactionTypeMultiSigCreate
+	case *generated.Transaction_MultiSigSpend_:
+		return TransactionTypeMultiSigSpend
+	case *generated.Transaction_MultiSigVote_:
+		return TransactionTypeMultiSigVote
+	case *generated.Transaction_ProposalCreate_:
+		return TransactionTypeProposalCreate
+	case *generated.Transaction_ProposalVote_:
+		return TransactionTypeProposalVote
+	default:
+		return TransactionTypeUnknown
+	}
+}
+
+func NewTransactionFromPBData(blockNumber int64, timestamp uint64, addrFrom common.Address,
+	pbTX *generated.Transaction) *Transaction {
+	t := &Transaction{
+		Hash:        misc.ToSizedHash(pbTX.TransactionHash),
+		BlockNumber: blockNumber,
+		Timestamp:   timestamp,
+		Type:        GetTransactionType(pbTX),
+		From:        addrFrom,
+		Outputs:     []*TransactionOutput{},
+		Fee:         int64(pbTX.Fee),
+	}
+	if len(addrFrom) != 0 {
+		t.Signer = xmss.GetXMSSAddressFromPK(pbTX.PublicKey)
+	}
+
+	switch pbTX.TransactionType.(type) {
+	case *generated.Transaction_Transfer_:
+		transferTX := pbTX.GetTransfer()
+		for i, addr := range transferTX.AddrsTo {
+			t.addOutput(addr, transferTX.Amounts[i])
+		}
+		t.Memo = toMemo(transferTX.MessageData)
+	case *generated.Transaction_Coinbase:
+		coinBaseTX := pbTX.GetCoinbase()
+		t.addOutput(coinBaseTX.AddrTo, coinBaseTX.Amount)
+	case *generated.Transaction_Message_:
+		messageTX := pbTX.GetMessage()
+		if len(messageTX.AddrTo) != 0 {
+			t.addOutput(messageTX.AddrTo, 0)
+		}
+		t.Memo = toMemo(messageTX.MessageHash)
+	case *generated.Transaction_Token_:
+		tokenTX := pbTX.GetToken()
+		for _, initialBalance := range tokenTX.InitialBalances {
+			t.addOutput(initialBalance.Address, initialBalance.Amount)
+		}
+		t.TokenTxHash = &t.Hash
+	case *generated.Transaction_TransferToken_:
+		transferTokenTX := pbTX.GetTransferToken()
+		for i, addr := range transferTokenTX.AddrsTo {
+			t.addOutput(addr, transferTokenTX.Amounts[i])
+		}
+		tokenTxHash := misc.ToSizedHash(transferTokenTX.TokenTxhash)
+		t.TokenTxHash = &tokenTxHash
+	case *generated.Transaction_MultiSigSpend_:
+		multiSigSpendTX := pbTX.GetMultiSigSpend()
+		for i, addr := range multiSigSpendTX.AddrsTo {
+			t.addOutput(addr, multiSigSpendTX.Amounts[i])
+		}
+		t.MultiSigAddress = misc.ToStringAddress(multiSigSpendTX.MultiSigAddress)
+		t.Pending = true
+	}
+
+	return t
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+	"github.com/theQRL/qrl-rich-list-indexer/xmss"
+)
+
+func TestNewTransactionFromPBData(t *testing.T) {
+	publicKey := make([]byte, xmss.ExtendedPKSize)
+	publicKey[1] = 0x05
+	signer := xmss.GetXMSSAddressFromPK(publicKey)
+	master := common.Address("Qmaster")
+	recipientA := []byte{0x0a}
+	recipientB := []byte{0x0b}
+	txHash := []byte{0x01, 0x02}
+	tokenTxHash := misc.ToSizedHash([]byte{0x03, 0x04})
+	multiSigAddress := misc.GetMultiSigAddress([]byte{0x05})
+	multiSigByteAddress, err := misc.ToByteAddress(multiSigAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := func(address []byte, amount int64) *TransactionOutput {
+		return &TransactionOutput{Address: misc.ToStringAddress(address), Amount: amount}
+	}
+
+	tests := []struct {
+		name     string
+		addrFrom common.Address
+		pbTX     *generated.Transaction
+		want     *Transaction
+	}{
+		{
+			name: "coinbase",
+			pbTX: &generated.Transaction{
+				TransactionType: &generated.Transaction_Coinbase{
+					Coinbase: &generated.Transaction_CoinBase{AddrTo: recipientA, Amount: 100},
+				},
+			},
+			want: &Transaction{
+				Type:    TransactionTypeCoinbase,
+				Outputs: []*TransactionOutput{output(recipientA, 100)},
+			},
+		},
+		{
+			name:     "transfer with text memo",
+			addrFrom: signer,
+			pbTX: &generated.Transaction{
+				Fee: 1,
+				TransactionType: &generated.Transaction_Transfer_{
+					Transfer: &generated.Transaction_Transfer{
+						AddrsTo:     [][]byte{recipientA, recipientB},
+						Amounts:     []uint64{10, 20},
+						MessageData: []byte("hello"),
+					},
+				},
+			},
+			want: &Transaction{
+				Type:    TransactionTypeTransfer,
+				From:    signer,
+				Signer:  signer,
+				Outputs: []*TransactionOutput{output(recipientA, 10), output(recipientB, 20)},
+				Fee:     1,
+				Memo:    "hello",
+			},
+		},
+		{
+			name:     "transfer signed by a slave with binary memo",
+			addrFrom: master,
+			pbTX: &generated.Transaction{
+				TransactionType: &generated.Transaction_Transfer_{
+					Transfer: &generated.Transaction_Transfer{
+						AddrsTo:     [][]byte{recipientA},
+						Amounts:     []uint64{10},
+						MessageData: []byte{0xff, 0xfe},
+					},
+				},
+			},
+			want: &Transaction{
+				Type:    TransactionTypeTransfer,
+				From:    master,
+				Signer:  signer,
+				Outputs: []*TransactionOutput{output(recipientA, 10)},
+				Memo:    "fffe",
+			},
+		},
+		{
+			name:     "transfer token",
+			addrFrom: signer,
+			pbTX: &generated.Transaction{
+				TransactionType: &generated.Transaction_TransferToken_{
+					TransferToken: &generated.Transaction_TransferToken{
+						TokenTxhash: tokenTxHash[:],
+						AddrsTo:     [][]byte{recipientA},
+						Amounts:     []uint64{5},
+					},
+				},
+			},
+			want: &Transaction{
+				Type:        TransactionTypeTransferToken,
+				From:        signer,
+				Signer:      signer,
+				Outputs:     []*TransactionOutput{output(recipientA, 5)},
+				TokenTxHash: &tokenTxHash,
+			},
+		},
+		{
+			name:     "multisig spend",
+			addrFrom: signer,
+			pbTX: &generated.Transaction{
+				TransactionType: &generated.Transaction_MultiSigSpend_{
+					MultiSigSpend: &generated.Transaction_MultiSigSpend{
+						MultiSigAddress: multiSigByteAddress[:],
+						AddrsTo:         [][]byte{recipientA},
+						Amounts:         []uint64{50},
+					},
+				},
+			},
+			want: &Transaction{
+				Type:    TransactionTypeMultiSigSpend,
+				From:    signer,
+				Signer:  signer,
+				Outputs: []*TransactionOutput{output(recipientA, 50)},
+
+				MultiSigAddress: multiSigAddress,
+				Pending:         true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.pbTX.TransactionHash = txHash
+			if len(tt.addrFrom) != 0 {
+				tt.pbTX.PublicKey = publicKey
+			}
+			tt.want.Hash = misc.ToSizedHash(txHash)
+			tt.want.BlockNumber = 7
+			tt.want.Timestamp = 1600000000
+
+			got := NewTransactionFromPBData(7, 1600000000, tt.addrFrom, tt.pbTX)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewTransactionFromPBData() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"encoding/hex"
+	"fmt"
 
 	"github.com/theQRL/qrl-rich-list-indexer/cache"
 	"github.com/theQRL/qrl-rich-list-indexer/common"
@@ -29,17 +30,152 @@ func AddDeleteOneModelIntoOperations(operations *[]mongo.WriteModel, model inter
 	*operations = append(*operations, operation)
 }
 
+// Number of blocks between two reports of the account cache hit rate
+const accountCacheReportInterval = 1000
+
+// ProcessBlock applies b in a transaction of its own
 func (m *MongoDBProcessor) ProcessBlock(b *generated.Block) error {
-	var blockOperations []mongo.WriteModel
+	return m.ProcessBlocks([]*generated.Block{b})
+}
+
+// ProcessBlocks applies consecutive blocks in a single transaction, so that
+// catching up does not pay for a transaction per block. Accounts are merged in
+// memory across the blocks, and only written before they have to be ranked.
+func (m *MongoDBProcessor) ProcessBlocks(blocks []*generated.Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	firstBlock := blocks[0]
+	lastBlock := blocks[len(blocks)-1]
+
+	session, err := m.client.StartSession(options.Session())
+	if err != nil {
+		m.log.Error("[ProcessBlocks] failed to start session")
+		return err
+	}
+	defer session.EndSession(m.ctx)
+
+	accountCache := make(cache.AccountCache)
+	err = mongo.WithSession(m.ctx, session, func(sctx mongo.SessionContext) error {
+		if err := sctx.StartTransaction(); err != nil {
+			return err
+		}
+
+		// Reads made while applying the blocks go through the transaction, so
+		// that each block sees the changes of the blocks before it
+		tm := m.withContext(sctx)
+		for _, b := range blocks {
+			if err := tm.processBlock(sctx, b, accountCache); err != nil {
+				m.log.Error("[ProcessBlocks] Failed to processBlock",
+					"Block #", b.Header.BlockNumber,
+					"Error", err.Error())
+				return err
+			}
+		}
+		if err := tm.writeAccounts(sctx, accountCache); err != nil {
+			return err
+		}
+
+		// Top holders are ranked after the account changes have been written in the transaction
+		topHoldersStatsOperations, err := tm.GetTopHoldersStatsOperations(sctx, int64(lastBlock.Header.BlockNumber))
+		if err != nil {
+			m.log.Error("Failed to GetTopHoldersStatsOperations")
+			return err
+		}
+		if len(topHoldersStatsOperations) > 0 {
+			if _, err := m.statsCollection.BulkWrite(sctx, topHoldersStatsOperations); err != nil {
+				m.log.Error("Failed to write in statsCollection",
+					"total operations", len(topHoldersStatsOperations))
+				return err
+			}
+		}
+		return sctx.CommitTransaction(sctx)
+	})
+	if err != nil {
+		// The commit may have failed after being applied
+		m.accountLRUCache.RemoveAll(accountCache)
+		m.log.Info("Failed to Process",
+			"From Block #", firstBlock.Header.BlockNumber,
+			"To Block #", lastBlock.Header.BlockNumber,
+			"Error", err)
+		return err
+	}
+	m.accountLRUCache.PutAll(accountCache)
+
+	for _, b := range blocks {
+		m.log.Info("Processed",
+			"Block #", b.Header.BlockNumber,
+			"HeaderHash", hex.EncodeToString(b.Header.HashHeader))
+		if b.Header.BlockNumber%accountCacheReportInterval == 0 {
+			m.log.Info("Account cache",
+				"Size", m.accountLRUCache.Len(),
+				"HitRate", fmt.Sprintf("%.2f%%", m.accountLRUCache.HitRate()*100))
+		}
+	}
+	return nil
+}
+
+// writeAccounts upserts every account of accountCache within sctx
+func (m *MongoDBProcessor) writeAccounts(sctx mongo.SessionContext, accountCache cache.AccountCache) error {
 	var accountOperations []mongo.WriteModel
+	for addr, account := range accountCache {
+		operation := mongo.NewUpdateOneModel()
+		operation.SetUpsert(true)
+		operation.SetFilter(bsonx.Doc{
+			{"address", bsonx.String(addr.ToString())},
+		})
+		operation.SetUpdate(bson.M{"$set": account})
+		accountOperations = append(accountOperations, operation)
+	}
+
+	if len(accountOperations) > 0 {
+		if _, err := m.accountsCollection.BulkWrite(sctx, accountOperations); err != nil {
+			m.log.Error("Failed to write in accountsCollection",
+				"total operations", len(accountOperations))
+			return err
+		}
+	}
+	return nil
+}
+
+// processBlock applies b within sctx. The accounts it changes are left in
+// accountCache, to be written by the caller.
+func (m *MongoDBProcessor) processBlock(sctx mongo.SessionContext, b *generated.Block,
+	accountCache cache.AccountCache) error {
+	var blockOperations []mongo.WriteModel
 	var balanceChangeLogOperations []mongo.WriteModel
+	var tokenOperations []mongo.WriteModel
+	var tokenHolderOperations []mongo.WriteModel
+	var tokenBalanceChangeLogOperations []mongo.WriteModel
+	var slaveOperations []mongo.WriteModel
+	var multiSigAddressOperations []mongo.WriteModel
+	var multiSigSpendOperations []mongo.WriteModel
+	var multiSigVoteOperations []mongo.WriteModel
+	var minerOperations []mongo.WriteModel
+	var minedBlockOperations []mongo.WriteModel
+	var proposalOperations []mongo.WriteModel
+	var proposalVoteOperations []mongo.WriteModel
+	var otsKeyUsageOperations []mongo.WriteModel
+	var otsKeyStatusOperations []mongo.WriteModel
+	var transactionOperations []mongo.WriteModel
+	var ruleAuditOperations []mongo.WriteModel
+	var statsOperations []mongo.WriteModel
 
-	blockNumber := int64(b.Header.BlockNumber)
-	blockModel := models.NewBlockFromPBData(b)
+	// Balances, multisig state and rules are computed as for every storage
+	// backend, everything else indexed from the block is recorded on top
+	changes, err := ComputeBlockChanges(b, m.newBlockState(accountCache), m.rules)
+	if err != nil {
+		m.log.Error("[ProcessBlock] Failed to ComputeBlockChanges",
+			"Error", err.Error())
+		return err
+	}
+	blockModel := changes.Block
+	blockNumber := blockModel.Number
+	balanceChangeLogCache := changes.BalanceChangeLogs
 	AddInsertOneModelIntoOperations(&blockOperations, blockModel)
 
 	reOrgLimit := common.BLOCKZERO + config.GetConfig().ReOrgLimit
-	if uint64(blockModel.Number) > reOrgLimit {
+	if !m.config.ArchiveMode && uint64(blockModel.Number) > reOrgLimit {
 		removeBlockNumber := uint64(blockModel.Number) - reOrgLimit
 		deleteOneOperation := mongo.NewDeleteOneModel()
 		deleteOneOperation.SetFilter(bsonx.Doc{
@@ -52,217 +188,393 @@ func (m *MongoDBProcessor) ProcessBlock(b *generated.Block) error {
 			{"blockNumber", bsonx.Int64(int64(removeBlockNumber))},
 		})
 		balanceChangeLogOperations = append(balanceChangeLogOperations, deleteManyOperation)
+
+		deleteManyOperation = mongo.NewDeleteManyModel()
+		deleteManyOperation.SetFilter(bson.M{"blockNumber": int64(removeBlockNumber)})
+		tokenBalanceChangeLogOperations = append(tokenBalanceChangeLogOperations, deleteManyOperation)
+	}
+	// Mined blocks are also kept for the widest miner share window
+	minedBlocksLimit := reOrgLimit
+	for _, window := range m.config.MinerShareWindows {
+		if common.BLOCKZERO+uint64(window) > minedBlocksLimit {
+			minedBlocksLimit = common.BLOCKZERO + uint64(window)
+		}
+	}
+	if !m.config.ArchiveMode && uint64(blockModel.Number) > minedBlocksLimit {
+		deleteManyOperation := mongo.NewDeleteManyModel()
+		deleteManyOperation.SetFilter(bson.M{"blockNumber": blockModel.Number - int64(minedBlocksLimit)})
+		minedBlockOperations = append(minedBlockOperations, deleteManyOperation)
 	}
 
-	accountCache := make(cache.AccountCache)
-	balanceChangeLogCache := make(cache.BalanceChangeLogCache)
+	tokenCache := make(cache.TokenCache)
+	tokenHolderCache := make(cache.TokenHolderCache)
+	tokenBalanceChangeLogCache := make(cache.TokenBalanceChangeLogCache)
+	minerCache := make(cache.MinerCache)
+	proposalCache := make(cache.ProposalCache)
+	var proposalVotes []*models.ProposalVote
+	otsKeyUsageCache := make(cache.OTSKeyUsageCache)
+	otsKeyStatusCache := make(cache.OTSKeyStatusCache)
 
 	for _, protoTX := range b.Transactions {
-		var addrFrom common.Address
-		totalAmountSpent := int64(protoTX.Fee)
+		addrFrom := getAddrFrom(protoTX)
 
-		switch protoTX.TransactionType.(type) {
-		case *generated.Transaction_Coinbase:
-		default:
-			addrFrom = xmss.GetXMSSAddressFromPK(protoTX.PublicKey)
-			if protoTX.MasterAddr != nil {
-				addrFrom = misc.ToStringAddress(protoTX.MasterAddr)
-			}
-		}
+		AddInsertOneModelIntoOperations(&transactionOperations,
+			models.NewTransactionFromPBData(blockNumber, b.Header.TimestampSeconds, addrFrom, protoTX))
 
 		switch protoTX.TransactionType.(type) {
 		case *generated.Transaction_Coinbase:
-			coinBaseTX := protoTX.GetCoinbase()
-			address := misc.ToStringAddress(coinBaseTX.AddrTo)
-			amount := int64(coinBaseTX.Amount)
+			address := misc.ToStringAddress(protoTX.GetCoinbase().AddrTo)
+			minedBlock := models.NewMinedBlockFromPBData(b, address)
+			AddInsertOneModelIntoOperations(&minedBlockOperations, minedBlock)
 
-			err := m.UpdateAccountAndLog(blockNumber, address, amount, accountCache, balanceChangeLogCache)
+			miner, err := m.GetMinerFromDBOrCache(address, minerCache)
 			if err != nil {
-				m.log.Error("[ProcessBlock] Failed to UpdateAccountAndLog for coinBase.AddrTo",
+				m.log.Error("[ProcessBlock] Failed to GetMinerFromDBOrCache for coinBase.AddrTo",
 					"Error", err.Error())
 				return err
 			}
+			miner.AddMinedBlock(minedBlock)
 		case *generated.Transaction_Transfer_:
-			transferTX := protoTX.GetTransfer()
-			for i, addr := range transferTX.AddrsTo {
-				address := misc.ToStringAddress(addr)
-				amount := int64(transferTX.Amounts[i])
-				totalAmountSpent += amount
-
-				err := m.UpdateAccountAndLog(blockNumber, address, amount, accountCache, balanceChangeLogCache)
-				if err != nil {
-					m.log.Error("[ProcessBlock] Failed to UpdateAccountAndLog for transferTX.AddrsTo",
-						"Error", err.Error())
-					return err
-				}
-			}
 		case *generated.Transaction_LatticePK:
 		case *generated.Transaction_Message_:
 		case *generated.Transaction_Token_:
-		case *generated.Transaction_TransferToken_:
-		case *generated.Transaction_Slave_:
-		case *generated.Transaction_MultiSigCreate_:
-		case *generated.Transaction_MultiSigSpend_:
-		case *generated.Transaction_MultiSigVote_:
-			// Get Vote Stats
-			// if not executed ignore
-			// Get maximum blocknumber from all tx_hashes in vote stats
-			// check if current blocknumber == maximum blocknumber
-			// if true, then get multisig spend and apply
-
-			multiSigVoteTX := protoTX.GetMultiSigVote()
-			respVoteStats, err := m.pac.GetVoteStats(context.Background(), &generated.GetVoteStatsReq{
-				MultiSigSpendTxHash: multiSigVoteTX.SharedKey})
-			if err != nil {
-				m.log.Error("[ProcessBlock] Error calling GetVoteStats",
-					"Error", err.Error())
-				return err
-			}
-			if !respVoteStats.VoteStats.Executed {
-				continue
-			}
-			maxBlockNumber := blockNumber
-			for _, txHash := range respVoteStats.VoteStats.TxHashes {
-				resp, err := m.pac.GetTransaction(context.Background(), &generated.GetTransactionReq{
-					TxHash: txHash})
+			tokenTX := protoTX.GetToken()
+			tokenTxHash := misc.ToSizedHash(protoTX.TransactionHash)
+			tokenCache.Put(tokenTxHash, models.NewTokenFromPBData(blockNumber, protoTX))
+			for _, initialBalance := range tokenTX.InitialBalances {
+				address := misc.ToStringAddress(initialBalance.Address)
+				amount := int64(initialBalance.Amount)
+
+				err := m.UpdateTokenHolderAndLog(blockNumber, tokenTxHash, address, amount,
+					tokenHolderCache, tokenBalanceChangeLogCache)
 				if err != nil {
-					m.log.Error("[ProcessBlock] Error calling GetTransaction",
+					m.log.Error("[ProcessBlock] Failed to UpdateTokenHolderAndLog for tokenTX.InitialBalances",
 						"Error", err.Error())
 					return err
 				}
-				innerTXBlockNumber := int64(resp.GetBlockNumber())
-				// Found a transaction at higher block number, so no need to process this transaction
-				if innerTXBlockNumber > maxBlockNumber {
-					maxBlockNumber = innerTXBlockNumber
-					break
-				}
-			}
-			if maxBlockNumber != blockNumber {
-				continue
-			}
-			respGetTX, err := m.pac.GetTransaction(context.Background(), &generated.GetTransactionReq{
-				TxHash: multiSigVoteTX.SharedKey})
-			if err != nil {
-				m.log.Error("[ProcessBlock] Error calling GetTransaction for multisig spend txn",
-					"Error", err.Error())
-				return err
 			}
-			multiSigTx := respGetTX.Tx.GetMultiSigSpend()
-			totalAmountSpentByMultiSig := int64(0)
-			for i, addr := range multiSigTx.AddrsTo {
+		case *generated.Transaction_TransferToken_:
+			transferTokenTX := protoTX.GetTransferToken()
+			tokenTxHash := misc.ToSizedHash(transferTokenTX.TokenTxhash)
+			totalTokenAmountSpent := int64(0)
+			for i, addr := range transferTokenTX.AddrsTo {
 				address := misc.ToStringAddress(addr)
-				amount := int64(multiSigTx.Amounts[i])
-				totalAmountSpentByMultiSig += amount
+				amount := int64(transferTokenTX.Amounts[i])
+				totalTokenAmountSpent += amount
 
-				err := m.UpdateAccountAndLog(blockNumber, address, amount, accountCache, balanceChangeLogCache)
+				err := m.UpdateTokenHolderAndLog(blockNumber, tokenTxHash, address, amount,
+					tokenHolderCache, tokenBalanceChangeLogCache)
 				if err != nil {
-					m.log.Error("[ProcessBlock] Failed to UpdateAccountAndLog for multiSigTx.AddrsTo",
+					m.log.Error("[ProcessBlock] Failed to UpdateTokenHolderAndLog for transferTokenTX.AddrsTo",
 						"Error", err.Error())
 					return err
 				}
 			}
 
-			multiSigAddress := misc.ToStringAddress(multiSigTx.MultiSigAddress)
-			err = m.UpdateAccountAndLog(blockNumber, multiSigAddress, totalAmountSpentByMultiSig*-1,
-				accountCache, balanceChangeLogCache)
+			err := m.UpdateTokenHolderAndLog(blockNumber, tokenTxHash, addrFrom, totalTokenAmountSpent*-1,
+				tokenHolderCache, tokenBalanceChangeLogCache)
 			if err != nil {
-				m.log.Error("[ProcessBlock] Failed to UpdateAccountAndLog for multiSigAddress",
+				m.log.Error("[ProcessBlock] Failed to UpdateTokenHolderAndLog for addrFrom",
+					"Error", err.Error())
+				return err
+			}
+		case *generated.Transaction_Slave_:
+			slaveTX := protoTX.GetSlave()
+			for i, slavePK := range slaveTX.SlavePks {
+				slave := models.NewSlave(blockNumber, protoTX.TransactionHash, addrFrom,
+					slavePK, slaveTX.AccessTypes[i])
+				AddInsertOneModelIntoOperations(&slaveOperations, slave)
+			}
+		case *generated.Transaction_MultiSigCreate_, *generated.Transaction_MultiSigSpend_,
+			*generated.Transaction_MultiSigVote_:
+			// Multisig state is part of the block changes
+		case *generated.Transaction_ProposalCreate_:
+			proposal := models.NewProposalFromPBData(blockNumber, addrFrom, m.config.ProposalDefaultOptions, protoTX)
+			proposalCache.Put(proposal.SharedKey, proposal)
+		case *generated.Transaction_ProposalVote_:
+			proposalVoteTX := protoTX.GetProposalVote()
+			sharedKey := misc.ToSizedHash(proposalVoteTX.SharedKey)
+			proposal, err := m.GetProposalFromDBOrCache(sharedKey, proposalCache)
+			if err == ErrNotFound {
+				m.log.Warn("[ProcessBlock] Proposal not found for ProposalVote",
+					"sharedKey", sharedKey.ToString())
+				break
+			} else if err != nil {
+				m.log.Error("[ProcessBlock] Failed to GetProposalFromDBOrCache for proposalVoteTX",
 					"Error", err.Error())
 				return err
 			}
+			if blockNumber > proposal.ExpiryBlockNumber || !proposal.IsValidOption(proposalVoteTX.Option) {
+				break
+			}
+			proposalVote := models.NewProposalVote(blockNumber, protoTX.TransactionHash, sharedKey,
+				addrFrom, proposalVoteTX.Option)
+			proposalVotes = append(proposalVotes, proposalVote)
+			AddInsertOneModelIntoOperations(&proposalVoteOperations, proposalVote)
 		default:
 			continue
 		}
 
 		if len(addrFrom) != 0 {
-			err := m.UpdateAccountAndLog(blockNumber, addrFrom, totalAmountSpent*-1,
-				accountCache, balanceChangeLogCache)
+			otsKeyUsage, err := m.RecordOTSKeyUsage(blockNumber, protoTX, otsKeyUsageCache, otsKeyStatusCache)
 			if err != nil {
-				m.log.Error("[ProcessBlock] Failed to UpdateAccountAndLog for addrFrom",
+				m.log.Error("[ProcessBlock] Failed to RecordOTSKeyUsage",
 					"Error", err.Error())
 				return err
 			}
+			if otsKeyUsage != nil {
+				AddInsertOneModelIntoOperations(&otsKeyUsageOperations, otsKeyUsage)
+			}
 		}
 	}
 
-	if uint64(blockNumber) >= m.config.BanStartBlockNumber {
-		for addr, _ := range m.config.BannedQRLAddressList {
-			account := models.NewAccount(common.Address(addr))
+	for _, ruleAudit := range changes.RuleAudits {
+		AddInsertOneModelIntoOperations(&ruleAuditOperations, ruleAudit)
+	}
+
+	err = m.FinalizeExpiredProposals(blockNumber, proposalCache, proposalVotes, accountCache)
+	if err != nil {
+		m.log.Error("[ProcessBlock] Failed to FinalizeExpiredProposals",
+			"Error", err.Error())
+		return err
+	}
+
+	for sharedKey, proposal := range proposalCache {
+		operation := mongo.NewUpdateOneModel()
+		operation.SetUpsert(true)
+		operation.SetFilter(bson.M{"sharedKey": sharedKey})
+		operation.SetUpdate(bson.M{"$set": proposal})
+		proposalOperations = append(proposalOperations, operation)
+	}
+
+	statsCache, err := m.UpdateStats(blockNumber, accountCache, balanceChangeLogCache)
+	if err != nil {
+		m.log.Error("[ProcessBlock] Failed to UpdateStats",
+			"Error", err.Error())
+		return err
+	}
+	statsOperations = AddStatsIntoOperations(statsOperations, statsCache)
+
+	for _, balanceChangeLog := range balanceChangeLogCache {
+		AddInsertOneModelIntoOperations(&balanceChangeLogOperations, balanceChangeLog)
+	}
+
+	for addr, otsKeyStatus := range otsKeyStatusCache {
+		operation := mongo.NewUpdateOneModel()
+		operation.SetUpsert(true)
+		operation.SetFilter(bson.M{"address": addr})
+		operation.SetUpdate(bson.M{"$set": otsKeyStatus})
+		otsKeyStatusOperations = append(otsKeyStatusOperations, operation)
+	}
+
+	for addr, miner := range minerCache {
+		operation := mongo.NewUpdateOneModel()
+		operation.SetUpsert(true)
+		operation.SetFilter(bson.M{"address": addr})
+		operation.SetUpdate(bson.M{"$set": miner})
+		minerOperations = append(minerOperations, operation)
+	}
+
+	for _, multiSigAddress := range changes.MultiSigAddresses {
+		AddInsertOneModelIntoOperations(&multiSigAddressOperations, multiSigAddress)
+	}
+
+	for sharedKey, multiSigSpend := range changes.MultiSigSpends {
+		operation := mongo.NewUpdateOneModel()
+		operation.SetUpsert(true)
+		operation.SetFilter(bson.M{"sharedKey": sharedKey})
+		operation.SetUpdate(bson.M{"$set": multiSigSpend})
+		multiSigSpendOperations = append(multiSigSpendOperations, operation)
 
+		if multiSigSpend.Executed && multiSigSpend.ExecutedBlockNumber == blockNumber {
 			operation := mongo.NewUpdateOneModel()
-			operation.SetUpsert(true)
-			operation.SetFilter(bsonx.Doc{
-				{"address", bsonx.String(addr)},
-			})
-			operation.SetUpdate(bson.M{"$set": account})
-			accountOperations = append(accountOperations, operation)
+			operation.SetFilter(bson.M{"hash": sharedKey})
+			operation.SetUpdate(bson.M{"$unset": bson.M{"pending": ""}})
+			transactionOperations = append(transactionOperations, operation)
 		}
 	}
 
-	for addr, account := range accountCache {
+	for _, multiSigVote := range changes.MultiSigVotes {
+		AddInsertOneModelIntoOperations(&multiSigVoteOperations, multiSigVote)
+	}
+
+	err = m.UpdateTokensFromChangeLogs(tokenCache, tokenHolderCache, tokenBalanceChangeLogCache)
+	if err != nil {
+		m.log.Error("[ProcessBlock] Failed to UpdateTokensFromChangeLogs",
+			"Error", err.Error())
+		return err
+	}
+
+	for txHash, token := range tokenCache {
 		operation := mongo.NewUpdateOneModel()
 		operation.SetUpsert(true)
-		operation.SetFilter(bsonx.Doc{
-			{"address", bsonx.String(addr.ToString())},
+		operation.SetFilter(bson.M{"txHash": txHash})
+		operation.SetUpdate(bson.M{"$set": token})
+		tokenOperations = append(tokenOperations, operation)
+	}
+
+	for _, tokenHolder := range tokenHolderCache {
+		operation := mongo.NewUpdateOneModel()
+		operation.SetUpsert(true)
+		operation.SetFilter(bson.M{
+			"tokenTxHash": tokenHolder.TokenTxHash,
+			"address":     tokenHolder.Address,
 		})
-		operation.SetUpdate(bson.M{"$set": account})
-		accountOperations = append(accountOperations, operation)
+		operation.SetUpdate(bson.M{"$set": tokenHolder})
+		tokenHolderOperations = append(tokenHolderOperations, operation)
 	}
 
-	for addr, balanceChangeLog := range balanceChangeLogCache {
-		if _, ok := m.config.BannedQRLAddressList[addr.ToString()]; ok {
-			if uint64(blockNumber) >= m.config.BanStartBlockNumber {
-				continue
-			}
-		}
-		AddInsertOneModelIntoOperations(&balanceChangeLogOperations, balanceChangeLog)
+	for _, tokenBalanceChangeLog := range tokenBalanceChangeLogCache {
+		AddInsertOneModelIntoOperations(&tokenBalanceChangeLogOperations, tokenBalanceChangeLog)
 	}
 
-	session, err := m.client.StartSession(options.Session())
+	richListSnapshotDue, richListSnapshotDay, err := m.IsRichListSnapshotDue(blockNumber, b.Header.TimestampSeconds)
 	if err != nil {
-		m.log.Error("[ProcessBlock] failed to start session")
+		m.log.Error("[ProcessBlock] Failed to IsRichListSnapshotDue",
+			"Error", err.Error())
 		return err
 	}
-	defer session.EndSession(m.ctx)
 
-	err = mongo.WithSession(m.ctx, session, func(sctx mongo.SessionContext) error {
-		if err := sctx.StartTransaction(); err != nil {
+	if _, err := m.blocksCollection.BulkWrite(sctx, blockOperations); err != nil {
+		m.log.Error("Failed to write in blocksCollection",
+			"total operations", len(blockOperations))
+		return err
+	}
+
+	if len(balanceChangeLogOperations) > 0 {
+		if _, err := m.balanceChangeLogsCollection.BulkWrite(sctx, balanceChangeLogOperations); err != nil {
+			m.log.Error("Failed to write in balanceChangeLogsCollection",
+				"total operations", len(balanceChangeLogOperations))
 			return err
 		}
-
-		if _, err := m.blocksCollection.BulkWrite(sctx, blockOperations); err != nil {
-			m.log.Error("Failed to write in blocksCollection",
-				"total operations", len(blockOperations))
+	}
+	if len(tokenOperations) > 0 {
+		if _, err := m.tokensCollection.BulkWrite(sctx, tokenOperations); err != nil {
+			m.log.Error("Failed to write in tokensCollection",
+				"total operations", len(tokenOperations))
 			return err
 		}
-
-		if len(accountOperations) > 0 {
-			if _, err := m.accountsCollection.BulkWrite(sctx, accountOperations); err != nil {
-				m.log.Error("Failed to write in accountsCollection",
-					"total operations", len(accountOperations))
-				return err
-			}
+	}
+	if len(tokenHolderOperations) > 0 {
+		if _, err := m.tokenHoldersCollection.BulkWrite(sctx, tokenHolderOperations); err != nil {
+			m.log.Error("Failed to write in tokenHoldersCollection",
+				"total operations", len(tokenHolderOperations))
+			return err
 		}
-		if len(balanceChangeLogOperations) > 0 {
-			if _, err := m.balanceChangeLogsCollection.BulkWrite(sctx, balanceChangeLogOperations); err != nil {
-				m.log.Error("Failed to write in balanceChangeLogsCollection",
-					"total operations", len(balanceChangeLogOperations))
-				return err
-			}
+	}
+	if len(tokenBalanceChangeLogOperations) > 0 {
+		if _, err := m.tokenBalanceChangeLogsCollection.BulkWrite(sctx, tokenBalanceChangeLogOperations); err != nil {
+			m.log.Error("Failed to write in tokenBalanceChangeLogsCollection",
+				"total operations", len(tokenBalanceChangeLogOperations))
+			return err
 		}
-		return sctx.CommitTransaction(sctx)
-	})
-	if err != nil {
-		m.log.Info("Failed to Process",
-			"Block #", b.Header.BlockNumber,
-			"HeaderHash", hex.EncodeToString(b.Header.HashHeader),
-			"Error", err)
+	}
+	if len(slaveOperations) > 0 {
+		if _, err := m.slavesCollection.BulkWrite(sctx, slaveOperations); err != nil {
+			m.log.Error("Failed to write in slavesCollection",
+				"total operations", len(slaveOperations))
+			return err
+		}
+	}
+	if len(multiSigAddressOperations) > 0 {
+		if _, err := m.multiSigAddressesCollection.BulkWrite(sctx, multiSigAddressOperations); err != nil {
+			m.log.Error("Failed to write in multiSigAddressesCollection",
+				"total operations", len(multiSigAddressOperations))
+			return err
+		}
+	}
+	if len(multiSigSpendOperations) > 0 {
+		if _, err := m.multiSigSpendsCollection.BulkWrite(sctx, multiSigSpendOperations); err != nil {
+			m.log.Error("Failed to write in multiSigSpendsCollection",
+				"total operations", len(multiSigSpendOperations))
+			return err
+		}
+	}
+	if len(multiSigVoteOperations) > 0 {
+		if _, err := m.multiSigVotesCollection.BulkWrite(sctx, multiSigVoteOperations); err != nil {
+			m.log.Error("Failed to write in multiSigVotesCollection",
+				"total operations", len(multiSigVoteOperations))
+			return err
+		}
+	}
+	if len(minerOperations) > 0 {
+		if _, err := m.minersCollection.BulkWrite(sctx, minerOperations); err != nil {
+			m.log.Error("Failed to write in minersCollection",
+				"total operations", len(minerOperations))
+			return err
+		}
+	}
+	if len(minedBlockOperations) > 0 {
+		if _, err := m.minedBlocksCollection.BulkWrite(sctx, minedBlockOperations); err != nil {
+			m.log.Error("Failed to write in minedBlocksCollection",
+				"total operations", len(minedBlockOperations))
+			return err
+		}
+	}
+	if len(proposalOperations) > 0 {
+		if _, err := m.proposalsCollection.BulkWrite(sctx, proposalOperations); err != nil {
+			m.log.Error("Failed to write in proposalsCollection",
+				"total operations", len(proposalOperations))
+			return err
+		}
+	}
+	if len(proposalVoteOperations) > 0 {
+		if _, err := m.proposalVotesCollection.BulkWrite(sctx, proposalVoteOperations); err != nil {
+			m.log.Error("Failed to write in proposalVotesCollection",
+				"total operations", len(proposalVoteOperations))
+			return err
+		}
+	}
+	if len(otsKeyUsageOperations) > 0 {
+		if _, err := m.otsKeyUsagesCollection.BulkWrite(sctx, otsKeyUsageOperations); err != nil {
+			m.log.Error("Failed to write in otsKeyUsagesCollection",
+				"total operations", len(otsKeyUsageOperations))
+			return err
+		}
+	}
+	if len(otsKeyStatusOperations) > 0 {
+		if _, err := m.otsKeyStatusesCollection.BulkWrite(sctx, otsKeyStatusOperations); err != nil {
+			m.log.Error("Failed to write in otsKeyStatusesCollection",
+				"total operations", len(otsKeyStatusOperations))
+			return err
+		}
+	}
+	if len(transactionOperations) > 0 {
+		if _, err := m.transactionsCollection.BulkWrite(sctx, transactionOperations); err != nil {
+			m.log.Error("Failed to write in transactionsCollection",
+				"total operations", len(transactionOperations))
+			return err
+		}
+	}
+	if len(ruleAuditOperations) > 0 {
+		if _, err := m.ruleAuditsCollection.BulkWrite(sctx, ruleAuditOperations); err != nil {
+			m.log.Error("Failed to write in ruleAuditsCollection",
+				"total operations", len(ruleAuditOperations))
+			return err
+		}
+	}
+	if _, err := m.statsCollection.BulkWrite(sctx, statsOperations); err != nil {
+		m.log.Error("Failed to write in statsCollection",
+			"total operations", len(statsOperations))
 		return err
 	}
-
-	m.log.Info("Processed",
-		"Block #", b.Header.BlockNumber,
-		"HeaderHash", hex.EncodeToString(b.Header.HashHeader))
+	if richListSnapshotDue {
+		// The accounts merged so far have to be written to be ranked
+		if err := m.writeAccounts(sctx, accountCache); err != nil {
+			return err
+		}
+		accounts, err := m.GetTopAccounts(sctx, blockNumber, m.config.RichListSnapshotSize)
+		if err != nil {
+			m.log.Error("Failed to GetTopAccounts for richListSnapshot")
+			return err
+		}
+		richListSnapshot := models.NewRichListSnapshot(blockNumber, b.Header.TimestampSeconds,
+			richListSnapshotDay, accounts)
+		if _, err := m.richListSnapshotsCollection.InsertOne(sctx, richListSnapshot); err != nil {
+			m.log.Error("Failed to write in richListSnapshotsCollection")
+			return err
+		}
+	}
 	return nil
 }
 
@@ -277,35 +589,171 @@ func (m *MongoDBProcessor) RevertLastBlock() error {
 	var blockOperations []mongo.WriteModel
 	var accountOperations []mongo.WriteModel
 	var balanceChangeLogOperations []mongo.WriteModel
+	var tokenOperations []mongo.WriteModel
+	var tokenHolderOperations []mongo.WriteModel
+	var tokenBalanceChangeLogOperations []mongo.WriteModel
+	var slaveOperations []mongo.WriteModel
+	var multiSigAddressOperations []mongo.WriteModel
+	var multiSigSpendOperations []mongo.WriteModel
+	var multiSigVoteOperations []mongo.WriteModel
+	var minerOperations []mongo.WriteModel
+	var minedBlockOperations []mongo.WriteModel
+	var proposalOperations []mongo.WriteModel
+	var proposalVoteOperations []mongo.WriteModel
+	var otsKeyUsageOperations []mongo.WriteModel
+	var otsKeyStatusOperations []mongo.WriteModel
+	var transactionOperations []mongo.WriteModel
+	var ruleAuditOperations []mongo.WriteModel
+	var statsOperations []mongo.WriteModel
+	var richListSnapshotOperations []mongo.WriteModel
 
 	var operation *mongo.UpdateOneModel
 	var deleteManyOperation *mongo.DeleteManyModel
 
 	accountCache := make(cache.AccountCache)
-	balanceChangeLogCache := make(cache.BalanceChangeLogCache)
 
-	balanceChangeLogs, err := m.GetBalanceChangeLogsByBlockNumber(b.Number)
+	changes, err := ComputeRevertChanges(b, m.newBlockState(accountCache))
 	if err != nil {
-		m.log.Error("[RevertLastBlock] Error calling GetTokenHolders",
+		m.log.Error("[RevertLastBlock] Failed to ComputeRevertChanges",
 			"Error", err.Error())
 		return err
 	}
+	balanceChangeLogCache := changes.BalanceChangeLogs
 
-	for _, balanceChangeLog := range balanceChangeLogs {
-		err := m.UpdateAccountAndLog(b.Number, balanceChangeLog.Address, balanceChangeLog.DeltaAmount*-1, accountCache, balanceChangeLogCache)
-		if err != nil {
-			m.log.Error("[ProcessBlock] Failed to UpdateAccountAndLog for coinBase.AddrTo",
-				"Error", err.Error())
-			return err
-		}
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	multiSigAddressOperations = append(multiSigAddressOperations, deleteManyOperation)
 
-		a := accountCache[balanceChangeLog.Address]
+	for sharedKey, multiSigSpend := range changes.MultiSigSpends {
 		operation = mongo.NewUpdateOneModel()
-		operation.SetFilter(bsonx.Doc{
-			{"address", bsonx.String(balanceChangeLog.Address.ToString())},
-		})
-		operation.SetUpdate(bson.M{"$set": a})
-		accountOperations = append(accountOperations, operation)
+		operation.SetFilter(bson.M{"sharedKey": sharedKey})
+		operation.SetUpdate(bson.M{"$set": multiSigSpend})
+		multiSigSpendOperations = append(multiSigSpendOperations, operation)
+	}
+
+	// Spends executed by the reverted block are pending again
+	executedMultiSigSpends, err := m.GetMultiSigSpendsByExecutedBlockNumber(b.Number)
+	if err != nil {
+		m.log.Error("[RevertLastBlock] Error calling GetMultiSigSpendsByExecutedBlockNumber",
+			"Error", err.Error())
+		return err
+	}
+
+	for _, executedMultiSigSpend := range executedMultiSigSpends {
+		operation = mongo.NewUpdateOneModel()
+		operation.SetFilter(bson.M{"hash": executedMultiSigSpend.SharedKey})
+		operation.SetUpdate(bson.M{"$set": bson.M{"pending": true}})
+		transactionOperations = append(transactionOperations, operation)
+	}
+
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	multiSigSpendOperations = append(multiSigSpendOperations, deleteManyOperation)
+
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	multiSigVoteOperations = append(multiSigVoteOperations, deleteManyOperation)
+
+	minedBlock, err := m.GetMinedBlockByNumber(b.Number)
+	if err != nil && err != ErrNotFound {
+		m.log.Error("[RevertLastBlock] Error calling GetMinedBlockByNumber",
+			"Error", err.Error())
+		return err
+	} else if err == nil {
+		miner, err := m.GetMinerByAddress(minedBlock.Miner)
+		if err != nil {
+			m.log.Error("[RevertLastBlock] Error calling GetMinerByAddress",
+				"Error", err.Error())
+			return err
+		}
+		miner.RevertMinedBlock(minedBlock)
+
+		operation = mongo.NewUpdateOneModel()
+		operation.SetFilter(bson.M{"address": miner.Address})
+		operation.SetUpdate(bson.M{"$set": miner})
+		minerOperations = append(minerOperations, operation)
+
+		AddDeleteOneModelIntoOperations(&minedBlockOperations, bson.M{"blockNumber": b.Number})
+	}
+
+	finalizedProposals, err := m.GetProposalsByFinalizedBlockNumber(b.Number)
+	if err != nil {
+		m.log.Error("[RevertLastBlock] Error calling GetProposalsByFinalizedBlockNumber",
+			"Error", err.Error())
+		return err
+	}
+
+	for _, proposal := range finalizedProposals {
+		// Proposals created by the reverted block are deleted below
+		if proposal.BlockNumber == b.Number {
+			continue
+		}
+		proposal.ResetFinalized()
+
+		operation = mongo.NewUpdateOneModel()
+		operation.SetFilter(bson.M{"sharedKey": proposal.SharedKey})
+		operation.SetUpdate(bson.M{"$set": proposal})
+		proposalOperations = append(proposalOperations, operation)
+	}
+
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	proposalOperations = append(proposalOperations, deleteManyOperation)
+
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	proposalVoteOperations = append(proposalVoteOperations, deleteManyOperation)
+
+	otsKeyStatusCache := make(cache.OTSKeyStatusCache)
+
+	otsKeyUsages, err := m.GetOTSKeyUsagesByBlockNumber(b.Number)
+	if err != nil {
+		m.log.Error("[RevertLastBlock] Error calling GetOTSKeyUsagesByBlockNumber",
+			"Error", err.Error())
+		return err
+	}
+
+	for _, otsKeyUsage := range otsKeyUsages {
+		otsKeyStatus := otsKeyStatusCache.Get(otsKeyUsage.Address)
+		if otsKeyStatus == nil {
+			otsKeyStatus, err = m.GetOTSKeyStatus(otsKeyUsage.Address)
+			if err != nil {
+				m.log.Error("[RevertLastBlock] Error calling GetOTSKeyStatus",
+					"Error", err.Error())
+				return err
+			}
+			otsKeyStatusCache.Put(otsKeyUsage.Address, otsKeyStatus)
+		}
+		otsKeyStatus.RevertUsage(otsKeyUsage, m.config.OTSKeyWarningRemainingPercentage)
+	}
+
+	for addr, otsKeyStatus := range otsKeyStatusCache {
+		operation = mongo.NewUpdateOneModel()
+		operation.SetFilter(bson.M{"address": addr})
+		operation.SetUpdate(bson.M{"$set": otsKeyStatus})
+		otsKeyStatusOperations = append(otsKeyStatusOperations, operation)
+	}
+
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	otsKeyUsageOperations = append(otsKeyUsageOperations, deleteManyOperation)
+
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	transactionOperations = append(transactionOperations, deleteManyOperation)
+
+	// Balance changes made by rules are part of the balance change logs, only the audit trail is left
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	ruleAuditOperations = append(ruleAuditOperations, deleteManyOperation)
+
+	for addr, a := range accountCache {
+		operation = mongo.NewUpdateOneModel()
+		operation.SetFilter(bsonx.Doc{
+			{"address", bsonx.String(addr.ToString())},
+		})
+		operation.SetUpdate(bson.M{"$set": a})
+		accountOperations = append(accountOperations, operation)
 	}
 
 	deleteManyOperation = mongo.NewDeleteManyModel()
@@ -314,8 +762,106 @@ func (m *MongoDBProcessor) RevertLastBlock() error {
 	})
 	balanceChangeLogOperations = append(balanceChangeLogOperations, deleteManyOperation)
 
+	tokenCache := make(cache.TokenCache)
+	tokenHolderCache := make(cache.TokenHolderCache)
+	tokenBalanceChangeLogCache := make(cache.TokenBalanceChangeLogCache)
+
+	tokenBalanceChangeLogs, err := m.GetTokenBalanceChangeLogsByBlockNumber(b.Number)
+	if err != nil {
+		m.log.Error("[RevertLastBlock] Error calling GetTokenBalanceChangeLogsByBlockNumber",
+			"Error", err.Error())
+		return err
+	}
+
+	for _, tokenBalanceChangeLog := range tokenBalanceChangeLogs {
+		err := m.UpdateTokenHolderAndLog(b.Number, tokenBalanceChangeLog.TokenTxHash, tokenBalanceChangeLog.Address,
+			tokenBalanceChangeLog.DeltaAmount*-1, tokenHolderCache, tokenBalanceChangeLogCache)
+		if err != nil {
+			m.log.Error("[RevertLastBlock] Failed to UpdateTokenHolderAndLog",
+				"Error", err.Error())
+			return err
+		}
+	}
+
+	err = m.UpdateTokensFromChangeLogs(tokenCache, tokenHolderCache, tokenBalanceChangeLogCache)
+	if err != nil {
+		m.log.Error("[RevertLastBlock] Failed to UpdateTokensFromChangeLogs",
+			"Error", err.Error())
+		return err
+	}
+
+	for txHash, token := range tokenCache {
+		// Tokens created by the reverted block are deleted below
+		if token.BlockNumber == b.Number {
+			continue
+		}
+		operation = mongo.NewUpdateOneModel()
+		operation.SetFilter(bson.M{"txHash": txHash})
+		operation.SetUpdate(bson.M{"$set": token})
+		tokenOperations = append(tokenOperations, operation)
+	}
+
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	tokenOperations = append(tokenOperations, deleteManyOperation)
+
+	// Holders of the tokens created by the reverted block are deleted with them
+	createdTokens, err := m.GetTokensByBlockNumber(b.Number)
+	if err != nil {
+		m.log.Error("[RevertLastBlock] Error calling GetTokensByBlockNumber",
+			"Error", err.Error())
+		return err
+	}
+	createdTokenTxHashes := make(map[common.Hash]bool)
+	for _, token := range createdTokens {
+		createdTokenTxHashes[token.TxHash] = true
+	}
+
+	for _, tokenHolder := range tokenHolderCache {
+		if createdTokenTxHashes[tokenHolder.TokenTxHash] {
+			continue
+		}
+		operation = mongo.NewUpdateOneModel()
+		operation.SetFilter(bson.M{
+			"tokenTxHash": tokenHolder.TokenTxHash,
+			"address":     tokenHolder.Address,
+		})
+		operation.SetUpdate(bson.M{"$set": tokenHolder})
+		tokenHolderOperations = append(tokenHolderOperations, operation)
+	}
+
+	if len(createdTokens) > 0 {
+		var tokenTxHashes []common.Hash
+		for tokenTxHash := range createdTokenTxHashes {
+			tokenTxHashes = append(tokenTxHashes, tokenTxHash)
+		}
+		deleteManyOperation = mongo.NewDeleteManyModel()
+		deleteManyOperation.SetFilter(bson.M{"tokenTxHash": bson.M{"$in": tokenTxHashes}})
+		tokenHolderOperations = append(tokenHolderOperations, deleteManyOperation)
+	}
+
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	tokenBalanceChangeLogOperations = append(tokenBalanceChangeLogOperations, deleteManyOperation)
+
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	slaveOperations = append(slaveOperations, deleteManyOperation)
+
+	deleteManyOperation = mongo.NewDeleteManyModel()
+	deleteManyOperation.SetFilter(bson.M{"blockNumber": b.Number})
+	richListSnapshotOperations = append(richListSnapshotOperations, deleteManyOperation)
+
 	AddDeleteOneModelIntoOperations(&blockOperations, b)
 
+	statsCache, err := m.UpdateStats(b.Number-1, accountCache, balanceChangeLogCache)
+	if err != nil {
+		m.log.Error("[RevertLastBlock] Failed to UpdateStats",
+			"Error", err.Error())
+		return err
+	}
+	statsOperations = AddStatsIntoOperations(statsOperations, statsCache)
+
 	session, err := m.client.StartSession(options.Session())
 	if err != nil {
 		m.log.Error("[RevertLastBlock] failed to start session")
@@ -348,15 +894,139 @@ func (m *MongoDBProcessor) RevertLastBlock() error {
 				return err
 			}
 		}
+		if len(tokenOperations) > 0 {
+			if _, err := m.tokensCollection.BulkWrite(sctx, tokenOperations); err != nil {
+				m.log.Error("Failed to write in tokensCollection",
+					"total operations", len(tokenOperations))
+				return err
+			}
+		}
+		if len(tokenHolderOperations) > 0 {
+			if _, err := m.tokenHoldersCollection.BulkWrite(sctx, tokenHolderOperations); err != nil {
+				m.log.Error("Failed to write in tokenHoldersCollection",
+					"total operations", len(tokenHolderOperations))
+				return err
+			}
+		}
+		if len(tokenBalanceChangeLogOperations) > 0 {
+			if _, err := m.tokenBalanceChangeLogsCollection.BulkWrite(sctx, tokenBalanceChangeLogOperations); err != nil {
+				m.log.Error("Failed to write in tokenBalanceChangeLogsCollection",
+					"total operations", len(tokenBalanceChangeLogOperations))
+				return err
+			}
+		}
+		if len(slaveOperations) > 0 {
+			if _, err := m.slavesCollection.BulkWrite(sctx, slaveOperations); err != nil {
+				m.log.Error("Failed to write in slavesCollection",
+					"total operations", len(slaveOperations))
+				return err
+			}
+		}
+		if len(multiSigAddressOperations) > 0 {
+			if _, err := m.multiSigAddressesCollection.BulkWrite(sctx, multiSigAddressOperations); err != nil {
+				m.log.Error("Failed to write in multiSigAddressesCollection",
+					"total operations", len(multiSigAddressOperations))
+				return err
+			}
+		}
+		if len(multiSigSpendOperations) > 0 {
+			if _, err := m.multiSigSpendsCollection.BulkWrite(sctx, multiSigSpendOperations); err != nil {
+				m.log.Error("Failed to write in multiSigSpendsCollection",
+					"total operations", len(multiSigSpendOperations))
+				return err
+			}
+		}
+		if len(multiSigVoteOperations) > 0 {
+			if _, err := m.multiSigVotesCollection.BulkWrite(sctx, multiSigVoteOperations); err != nil {
+				m.log.Error("Failed to write in multiSigVotesCollection",
+					"total operations", len(multiSigVoteOperations))
+				return err
+			}
+		}
+		if len(minerOperations) > 0 {
+			if _, err := m.minersCollection.BulkWrite(sctx, minerOperations); err != nil {
+				m.log.Error("Failed to write in minersCollection",
+					"total operations", len(minerOperations))
+				return err
+			}
+		}
+		if len(minedBlockOperations) > 0 {
+			if _, err := m.minedBlocksCollection.BulkWrite(sctx, minedBlockOperations); err != nil {
+				m.log.Error("Failed to write in minedBlocksCollection",
+					"total operations", len(minedBlockOperations))
+				return err
+			}
+		}
+		if len(proposalOperations) > 0 {
+			if _, err := m.proposalsCollection.BulkWrite(sctx, proposalOperations); err != nil {
+				m.log.Error("Failed to write in proposalsCollection",
+					"total operations", len(proposalOperations))
+				return err
+			}
+		}
+		if len(proposalVoteOperations) > 0 {
+			if _, err := m.proposalVotesCollection.BulkWrite(sctx, proposalVoteOperations); err != nil {
+				m.log.Error("Failed to write in proposalVotesCollection",
+					"total operations", len(proposalVoteOperations))
+				return err
+			}
+		}
+		if len(otsKeyUsageOperations) > 0 {
+			if _, err := m.otsKeyUsagesCollection.BulkWrite(sctx, otsKeyUsageOperations); err != nil {
+				m.log.Error("Failed to write in otsKeyUsagesCollection",
+					"total operations", len(otsKeyUsageOperations))
+				return err
+			}
+		}
+		if len(otsKeyStatusOperations) > 0 {
+			if _, err := m.otsKeyStatusesCollection.BulkWrite(sctx, otsKeyStatusOperations); err != nil {
+				m.log.Error("Failed to write in otsKeyStatusesCollection",
+					"total operations", len(otsKeyStatusOperations))
+				return err
+			}
+		}
+		if len(transactionOperations) > 0 {
+			if _, err := m.transactionsCollection.BulkWrite(sctx, transactionOperations); err != nil {
+				m.log.Error("Failed to write in transactionsCollection",
+					"total operations", len(transactionOperations))
+				return err
+			}
+		}
+		if len(ruleAuditOperations) > 0 {
+			if _, err := m.ruleAuditsCollection.BulkWrite(sctx, ruleAuditOperations); err != nil {
+				m.log.Error("Failed to write in ruleAuditsCollection",
+					"total operations", len(ruleAuditOperations))
+				return err
+			}
+		}
+		// Top holders are ranked after the account changes have been written in the transaction
+		topHoldersStatsOperations, err := m.GetTopHoldersStatsOperations(sctx, b.Number-1)
+		if err != nil {
+			m.log.Error("Failed to GetTopHoldersStatsOperations")
+			return err
+		}
+		statsOperations = append(statsOperations, topHoldersStatsOperations...)
+		if _, err := m.statsCollection.BulkWrite(sctx, statsOperations); err != nil {
+			m.log.Error("Failed to write in statsCollection",
+				"total operations", len(statsOperations))
+			return err
+		}
+		if _, err := m.richListSnapshotsCollection.BulkWrite(sctx, richListSnapshotOperations); err != nil {
+			m.log.Error("Failed to write in richListSnapshotsCollection",
+				"total operations", len(richListSnapshotOperations))
+			return err
+		}
 		return sctx.CommitTransaction(sctx)
 	})
 	if err != nil {
+		m.accountLRUCache.RemoveAll(accountCache)
 		m.log.Info("Failed to Revert",
 			"Block #", b.Number,
 			"HeaderHash", b.Hash.ToString(),
 			"Error", err)
 		return err
 	}
+	m.accountLRUCache.PutAll(accountCache)
 
 	m.log.Info("Reverted",
 		"Block #", b.Number,
@@ -364,11 +1034,34 @@ func (m *MongoDBProcessor) RevertLastBlock() error {
 	return nil
 }
 
+// mongoBlockState is the BlockState of the blocks being applied or reverted
+// through m. Accounts are merged in accountCache, across the blocks of a batch.
+type mongoBlockState struct {
+	*MongoDBProcessor
+	accountCache cache.AccountCache
+}
+
+func (m *MongoDBProcessor) newBlockState(accountCache cache.AccountCache) *mongoBlockState {
+	return &mongoBlockState{
+		MongoDBProcessor: m,
+		accountCache:     accountCache,
+	}
+}
+
+func (s *mongoBlockState) GetAccountByAddress(address common.Address) (*models.Account, error) {
+	return s.GetAccountFromDBOrCache(address, s.accountCache)
+}
+
 func (m *MongoDBProcessor) GetAccountFromDBOrCache(address common.Address, ac cache.AccountCache) (*models.Account, error) {
 	a, ok := ac[address]
 	if ok {
 		return a, nil
 	}
+	a = m.accountLRUCache.Get(address)
+	if a != nil {
+		ac.Put(address, a)
+		return a, nil
+	}
 	a, err := m.GetAccountByAddress(address)
 	if err != nil {
 		return nil, err
@@ -378,14 +1071,434 @@ func (m *MongoDBProcessor) GetAccountFromDBOrCache(address common.Address, ac ca
 
 	return a, nil
 }
-func (m *MongoDBProcessor) UpdateAccountAndLog(blockNumber int64, address common.Address,
-	amount int64, accountCache cache.AccountCache, balanceChangeLogCache cache.BalanceChangeLogCache) error {
-	a, err := m.GetAccountFromDBOrCache(address, accountCache)
+func (m *MongoDBProcessor) GetTokenFromDBOrCache(txHash common.Hash, tc cache.TokenCache) (*models.Token, error) {
+	t := tc.Get(txHash)
+	if t != nil {
+		return t, nil
+	}
+	t, err := m.GetTokenByTxHash(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	tc.Put(txHash, t)
+
+	return t, nil
+}
+
+// UpdateTokensFromChangeLogs applies the token balance changes of a block to
+// the holder count and circulating amount of the affected tokens
+func (m *MongoDBProcessor) UpdateTokensFromChangeLogs(tokenCache cache.TokenCache,
+	tokenHolderCache cache.TokenHolderCache, tokenBalanceChangeLogCache cache.TokenBalanceChangeLogCache) error {
+	for key, tokenBalanceChangeLog := range tokenBalanceChangeLogCache {
+		if tokenBalanceChangeLog.DeltaAmount == 0 {
+			continue
+		}
+		t, err := m.GetTokenFromDBOrCache(key.TokenTxHash, tokenCache)
+		if err == ErrNotFound {
+			m.log.Warn("Token not found for token balance change",
+				"tokenTxHash", key.TokenTxHash.ToString())
+			continue
+		} else if err != nil {
+			return err
+		}
+		tokenHolder := tokenHolderCache.Get(key.TokenTxHash, key.Address)
+		prevBalance := tokenHolder.Balance - tokenBalanceChangeLog.DeltaAmount
+		t.UpdateFromHolderBalance(prevBalance, tokenBalanceChangeLog.DeltaAmount)
+	}
+
+	return nil
+}
+
+func (m *MongoDBProcessor) GetMinerFromDBOrCache(address common.Address, mc cache.MinerCache) (*models.Miner, error) {
+	miner := mc.Get(address)
+	if miner != nil {
+		return miner, nil
+	}
+	miner, err := m.GetMinerByAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	mc.Put(address, miner)
+
+	return miner, nil
+}
+
+func (m *MongoDBProcessor) GetProposalFromDBOrCache(sharedKey common.Hash, pc cache.ProposalCache) (*models.Proposal, error) {
+	p := pc.Get(sharedKey)
+	if p != nil {
+		return p, nil
+	}
+	p, err := m.GetProposal(sharedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	pc.Put(sharedKey, p)
+
+	return p, nil
+}
+
+// TallyProposal weighs the latest vote of every voter by the voter's balance
+// as found in accountCache or the database
+func (m *MongoDBProcessor) TallyProposal(p *models.Proposal, proposalVotes []*models.ProposalVote,
+	accountCache cache.AccountCache) ([]*models.ProposalOptionTally, error) {
+	latestVotes := make(map[common.Address]uint32)
+	for _, proposalVote := range proposalVotes {
+		latestVotes[proposalVote.Voter] = proposalVote.Option
+	}
+
+	tally := p.NewTally()
+	for voter, option := range latestVotes {
+		a, err := m.GetAccountFromDBOrCache(voter, accountCache)
+		if err != nil {
+			return nil, err
+		}
+		if a.Balance > 0 {
+			tally[option].Weight += a.Balance
+		}
+		tally[option].Voters++
+	}
+
+	return tally, nil
+}
+
+// FinalizeExpiredProposals finalizes the tally of the proposals expiring at blockNumber,
+// using the balances after all the changes made by the block
+func (m *MongoDBProcessor) FinalizeExpiredProposals(blockNumber int64, proposalCache cache.ProposalCache,
+	blockProposalVotes []*models.ProposalVote, accountCache cache.AccountCache) error {
+	expiringProposals, err := m.GetProposalsByExpiryBlockNumber(blockNumber)
+	if err != nil {
+		return err
+	}
+	for _, proposal := range expiringProposals {
+		if proposalCache.Get(proposal.SharedKey) == nil {
+			proposalCache.Put(proposal.SharedKey, proposal)
+		}
+	}
+
+	for sharedKey, proposal := range proposalCache {
+		if proposal.ExpiryBlockNumber != blockNumber || proposal.Finalized {
+			continue
+		}
+		proposalVotes, err := m.GetProposalVotes(sharedKey)
+		if err != nil {
+			return err
+		}
+		for _, proposalVote := range blockProposalVotes {
+			if proposalVote.SharedKey == sharedKey {
+				proposalVotes = append(proposalVotes, proposalVote)
+			}
+		}
+		tally, err := m.TallyProposal(proposal, proposalVotes, accountCache)
+		if err != nil {
+			return err
+		}
+		proposal.SetFinalized(blockNumber, tally)
+	}
+
+	return nil
+}
+
+// RecordOTSKeyUsage records the OTS key used to sign protoTX against the
+// signing address, and returns nil if the signature carries no OTS index
+func (m *MongoDBProcessor) RecordOTSKeyUsage(blockNumber int64, protoTX *generated.Transaction,
+	otsKeyUsageCache cache.OTSKeyUsageCache, otsKeyStatusCache cache.OTSKeyStatusCache) (*models.OTSKeyUsage, error) {
+	otsIndex, ok := models.GetOTSIndexFromSignature(protoTX.Signature)
+	if !ok || len(protoTX.PublicKey) < xmss.DescriptorSize {
+		return nil, nil
+	}
+	signerAddress := xmss.GetXMSSAddressFromPK(protoTX.PublicKey)
+
+	reused := otsKeyUsageCache.Get(signerAddress, otsIndex) != nil
+	if !reused {
+		used, err := m.IsOTSKeyUsed(signerAddress, otsIndex)
+		if err != nil {
+			return nil, err
+		}
+		reused = used
+	}
+	otsKeyUsage := models.NewOTSKeyUsage(blockNumber, protoTX.TransactionHash, signerAddress, otsIndex, reused)
+	otsKeyUsageCache.Put(signerAddress, otsIndex, otsKeyUsage)
+
+	otsKeyStatus := otsKeyStatusCache.Get(signerAddress)
+	if otsKeyStatus == nil {
+		var err error
+		otsKeyStatus, err = m.GetOTSKeyStatus(signerAddress)
+		if err == ErrNotFound {
+			desc := xmss.NewQRLDescriptorFromBytes(protoTX.PublicKey[:xmss.DescriptorSize])
+			otsKeyStatus = models.NewOTSKeyStatus(signerAddress, desc.GetHeight())
+		} else if err != nil {
+			return nil, err
+		}
+		otsKeyStatusCache.Put(signerAddress, otsKeyStatus)
+	}
+	otsKeyStatus.AddUsage(otsKeyUsage, m.config.OTSKeyWarningRemainingPercentage)
+
+	return otsKeyUsage, nil
+}
+
+func (m *MongoDBProcessor) GetTokenHolderFromDBOrCache(tokenTxHash common.Hash, address common.Address,
+	tc cache.TokenHolderCache) (*models.TokenHolder, error) {
+	t := tc.Get(tokenTxHash, address)
+	if t != nil {
+		return t, nil
+	}
+	t, err := m.GetTokenHolder(tokenTxHash, address)
+	if err != nil {
+		return nil, err
+	}
+
+	tc.Put(tokenTxHash, address, t)
+
+	return t, nil
+}
+
+func (m *MongoDBProcessor) UpdateTokenHolderAndLog(blockNumber int64, tokenTxHash common.Hash, address common.Address,
+	amount int64, tokenHolderCache cache.TokenHolderCache, tokenBalanceChangeLogCache cache.TokenBalanceChangeLogCache) error {
+	t, err := m.GetTokenHolderFromDBOrCache(tokenTxHash, address, tokenHolderCache)
 	if err != nil {
 		return err
 	}
-	a.UpdateBalance(amount)
-	balanceChangeLogCache.Update(blockNumber, address, amount)
+	t.UpdateBalance(amount)
+	tokenBalanceChangeLogCache.Update(blockNumber, tokenTxHash, address, amount)
 
 	return nil
 }
+
+// ReconcileRules compares the loaded rules with the rule set the indexed data
+// was built with. If a rule changed, the blocks from the earliest changed
+// height onwards are reverted, so that they are reindexed with the new rules.
+func (m *MongoDBProcessor) ReconcileRules() error {
+	ruleSet, err := m.GetRuleSet()
+	if err == ErrNotFound {
+		return m.SaveRuleSet()
+	} else if err != nil {
+		return err
+	}
+	if ruleSet.Fingerprint == m.rules.Fingerprint() {
+		return nil
+	}
+
+	height, changed := m.rules.EarliestChangedHeight(ruleSet.Rules)
+	if changed {
+		b, err := m.GetLastBlock()
+		if err == ErrNotFound {
+			return m.SaveRuleSet()
+		} else if err != nil {
+			return err
+		}
+
+		if b.Number >= int64(height) {
+			if height == common.BLOCKZERO {
+				return fmt.Errorf("rules changed from genesis, drop database %s and reindex",
+					m.config.GetMongoDBConfig().DBName)
+			}
+			// Reverting stops at the block before height, which has to be retained
+			_, err := m.GetBlockByNumber(int64(height) - 1)
+			if err == ErrNotFound {
+				return fmt.Errorf("rules changed at height %d beyond ReOrgLimit, drop database %s and reindex",
+					height, m.config.GetMongoDBConfig().DBName)
+			} else if err != nil {
+				return err
+			}
+
+			m.log.Info("Rules changed, reverting blocks to reindex",
+				"from height", height,
+				"to height", b.Number)
+			for b.Number >= int64(height) {
+				err = m.RevertLastBlock()
+				if err != nil {
+					return err
+				}
+				b, err = m.GetLastBlock()
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return m.SaveRuleSet()
+}
+
+func (m *MongoDBProcessor) GetStatsFromDBOrCache(name string, sc cache.StatsCache) (*models.Stats, error) {
+	s := sc.Get(name)
+	if s != nil {
+		return s, nil
+	}
+	s, err := m.GetStatsByName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	sc.Put(name, s)
+
+	return s, nil
+}
+
+// UpdateStats updates the supply and holder counts from the balance changes of
+// a block being applied or reverted, and sets the last indexed height
+func (m *MongoDBProcessor) UpdateStats(lastIndexedHeight int64, accountCache cache.AccountCache,
+	balanceChangeLogCache cache.BalanceChangeLogCache) (cache.StatsCache, error) {
+	statsCache := make(cache.StatsCache)
+	statsCache.Put(models.StatsLastIndexedHeight, models.NewStats(models.StatsLastIndexedHeight, lastIndexedHeight))
+
+	totalSupply, err := m.GetStatsFromDBOrCache(models.StatsTotalSupply, statsCache)
+	if err != nil {
+		return nil, err
+	}
+	nonZeroHolders, err := m.GetStatsFromDBOrCache(models.StatsNonZeroHolders, statsCache)
+	if err != nil {
+		return nil, err
+	}
+	holdersAbove := make([]*models.Stats, len(m.config.HolderThresholds))
+	for i, threshold := range m.config.HolderThresholds {
+		holdersAbove[i], err = m.GetStatsFromDBOrCache(models.StatsHoldersAbove(threshold), statsCache)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	countChange := func(prevBalance int64, balance int64, threshold int64) int64 {
+		if prevBalance <= threshold && balance > threshold {
+			return 1
+		} else if prevBalance > threshold && balance <= threshold {
+			return -1
+		}
+		return 0
+	}
+
+	for addr, balanceChangeLog := range balanceChangeLogCache {
+		balance := accountCache.Get(addr).Balance
+		prevBalance := balance - balanceChangeLog.DeltaAmount
+
+		totalSupply.UpdateValue(balanceChangeLog.DeltaAmount)
+		nonZeroHolders.UpdateValue(countChange(prevBalance, balance, 0))
+		for i, threshold := range m.config.HolderThresholds {
+			holdersAbove[i].UpdateValue(countChange(prevBalance, balance, threshold))
+		}
+	}
+
+	return statsCache, nil
+}
+
+// GetTopAccounts returns the limit accounts with the highest balance, as seen
+// by ctx, leaving out the addresses excluded by rules at height
+func (m *MongoDBProcessor) GetTopAccounts(ctx context.Context, height int64, limit int64) ([]*models.Account, error) {
+	var accounts []*models.Account
+
+	query := bson.M{"balance": bson.M{"$gt": 0}}
+	excludedAddresses := m.rules.ExcludedAddresses(uint64(height))
+	if len(excludedAddresses) > 0 {
+		query["address"] = bson.M{"$nin": excludedAddresses}
+	}
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "balance", Value: -1}}
+	o.SetLimit(limit)
+	o.SetProjection(bson.M{"address": 1, "balance": 1})
+
+	cursor, err := m.accountsCollection.Find(ctx, query, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		a := &models.Account{}
+		err = cursor.Decode(a)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+
+	return accounts, cursor.Err()
+}
+
+// GetTopHoldersStatsOperations ranks the accounts as seen by sctx and returns the
+// operations updating the total balance of the top holders
+func (m *MongoDBProcessor) GetTopHoldersStatsOperations(sctx mongo.SessionContext, height int64) ([]mongo.WriteModel, error) {
+	var statsOperations []mongo.WriteModel
+
+	maxCount := int64(0)
+	for _, count := range m.config.TopHolderCounts {
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+
+	accounts, err := m.GetTopAccounts(sctx, height, maxCount)
+	if err != nil {
+		return nil, err
+	}
+
+	statsCache := make(cache.StatsCache)
+	for _, count := range m.config.TopHolderCounts {
+		topBalance := int64(0)
+		for i := int64(0); i < count && i < int64(len(accounts)); i++ {
+			topBalance += accounts[i].Balance
+		}
+		name := models.StatsTopBalance(count)
+		statsCache.Put(name, models.NewStats(name, topBalance))
+	}
+
+	return AddStatsIntoOperations(statsOperations, statsCache), nil
+}
+
+// IsRichListSnapshotDue returns whether a rich list snapshot has to be taken at
+// the block, and the UTC day to record if it is the first block of that day.
+// A block is the first of its day when the previous block is from an earlier day.
+// Blocks indexed before their timestamp was stored have none, so that the day
+// of the latest daily snapshot stands in for the day of the previous block.
+func (m *MongoDBProcessor) IsRichListSnapshotDue(blockNumber int64, timestamp uint64) (bool, string, error) {
+	day := models.GetRichListSnapshotDay(timestamp)
+	if blockNumber == common.BLOCKZERO {
+		_, err := m.GetRichListSnapshotByDay(day)
+		if err == ErrNotFound {
+			return true, day, nil
+		} else if err != nil {
+			return false, "", err
+		}
+	} else {
+		prevBlock, err := m.GetBlockByNumber(blockNumber - 1)
+		if err != nil {
+			return false, "", err
+		}
+		prevDay := models.GetRichListSnapshotDay(prevBlock.Timestamp)
+		if prevBlock.Timestamp == 0 {
+			s, err := m.GetLatestDailyRichListSnapshot(blockNumber)
+			if err == ErrNotFound {
+				return true, day, nil
+			} else if err != nil {
+				return false, "", err
+			}
+			prevDay = s.Day
+		}
+		if prevDay != day {
+			return true, day, nil
+		}
+	}
+
+	interval := m.config.RichListSnapshotInterval
+	return interval > 0 && blockNumber%interval == 0, "", nil
+}
+
+func AddStatsIntoOperations(operations []mongo.WriteModel, statsCache cache.StatsCache) []mongo.WriteModel {
+	for name, s := range statsCache {
+		operation := mongo.NewUpdateOneModel()
+		operation.SetUpsert(true)
+		operation.SetFilter(bson.M{"name": name})
+		operation.SetUpdate(bson.M{"$set": s})
+		operations = append(operations, operation)
+	}
+	return operations
+}
+
+func (m *MongoDBProcessor) SaveRuleSet() error {
+	o := options.Replace().SetUpsert(true)
+	_, err := m.ruleSetsCollection.ReplaceOne(m.ctx, bson.M{}, models.NewRuleSet(m.rules), o)
+	return err
+}
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"github.com/theQRL/qrl-rich-list-indexer/cache"
 	"github.com/theQRL/qrl-rich-list-indexer/common"
 	"github.com/theQRL/qrl-rich-list-indexer/db/models"
 	"go.mongodb.org/mongo-driver/bson"
@@ -14,7 +15,9 @@ func (m *MongoDBProcessor) GetLastBlock() (*models.Block, error) {
 
 	result := m.blocksCollection.FindOne(m.ctx, bson.D{{}}, o)
 
-	if result.Err() != nil {
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
 		return nil, result.Err()
 	}
 
@@ -33,7 +36,9 @@ func (m *MongoDBProcessor) GetBlockByNumber(number int64) (*models.Block, error)
 	result := m.blocksCollection.FindOne(m.ctx,
 		bson.D{{"number", number}}, o)
 
-	if result.Err() != nil {
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
 		return nil, result.Err()
 	}
 
@@ -63,9 +68,111 @@ func (m *MongoDBProcessor) GetAccountByAddress(address common.Address) (*models.
 	if err != nil {
 		return nil, err
 	}
+	// Accounts indexed before address descriptors were tracked are tagged when loaded
+	if a.Descriptor == nil {
+		a.Descriptor = models.NewAddressDescriptor(address)
+	}
 	return a, nil
 }
 
+// GetRichList returns the accounts with non zero balance ordered by balance in
+// descending order, restricted to the address type matching filter if not nil.
+// Addresses excluded by rules at the last indexed block are left out.
+func (m *MongoDBProcessor) GetRichList(filter *models.AddressTypeFilter, skip int64, limit int64) ([]*models.Account, error) {
+	var accounts []*models.Account
+
+	query := bson.M{"balance": bson.M{"$gt": 0}}
+
+	b, err := m.GetLastBlock()
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	} else if err == nil {
+		excludedAddresses := m.rules.ExcludedAddresses(b.GetNumber())
+		if len(excludedAddresses) > 0 {
+			query["address"] = bson.M{"$nin": excludedAddresses}
+		}
+	}
+
+	if filter != nil {
+		// Accounts missing their descriptor would silently be left out
+		if err := m.requireSchemaVersion(accountsDescriptorSchemaVersion); err != nil {
+			return nil, err
+		}
+		if filter.HashFunction != nil {
+			query["descriptor.hashFunction"] = *filter.HashFunction
+		}
+		if filter.TreeHeight != nil {
+			query["descriptor.treeHeight"] = *filter.TreeHeight
+		}
+	}
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "balance", Value: -1}}
+	o.SetSkip(skip)
+	o.SetLimit(limit)
+
+	cursor, err := m.accountsCollection.Find(m.ctx, query, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		a := &models.Account{}
+		err = cursor.Decode(a)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+
+	return accounts, cursor.Err()
+}
+
+func (m *MongoDBProcessor) getAddressTypeStats(field string) ([]*models.AddressTypeStats, error) {
+	var addressTypeStats []*models.AddressTypeStats
+
+	if err := m.requireSchemaVersion(accountsDescriptorSchemaVersion); err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"balance": bson.M{"$gt": 0}, "descriptor": bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":          "$descriptor." + field,
+			"holderCount":  bson.M{"$sum": 1},
+			"totalBalance": bson.M{"$sum": "$balance"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := m.accountsCollection.Aggregate(m.ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		s := &models.AddressTypeStats{}
+		err = cursor.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		addressTypeStats = append(addressTypeStats, s)
+	}
+
+	return addressTypeStats, cursor.Err()
+}
+
+// GetAddressTypeStatsByHashFunction returns holder count and total balance per hash function
+func (m *MongoDBProcessor) GetAddressTypeStatsByHashFunction() ([]*models.AddressTypeStats, error) {
+	return m.getAddressTypeStats("hashFunction")
+}
+
+// GetAddressTypeStatsByTreeHeight returns holder count and total balance per tree height
+func (m *MongoDBProcessor) GetAddressTypeStatsByTreeHeight() ([]*models.AddressTypeStats, error) {
+	return m.getAddressTypeStats("treeHeight")
+}
+
 func (m *MongoDBProcessor) GetBalanceChangeLogsByBlockNumber(blockNumber int64) ([]*models.BalanceChangeLog, error) {
 	var balanceChangeLogs []*models.BalanceChangeLog
 
@@ -77,6 +184,7 @@ func (m *MongoDBProcessor) GetBalanceChangeLogsByBlockNumber(blockNumber int64)
 	if err != nil {
 		return nil, err
 	}
+	defer cursor.Close(m.ctx)
 
 	for cursor.Next(m.ctx) {
 		t := &models.BalanceChangeLog{}
@@ -87,5 +195,959 @@ func (m *MongoDBProcessor) GetBalanceChangeLogsByBlockNumber(blockNumber int64)
 		balanceChangeLogs = append(balanceChangeLogs, t)
 	}
 
-	return balanceChangeLogs, nil
+	return balanceChangeLogs, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetFirstBlock() (*models.Block, error) {
+	o := &options.FindOneOptions{}
+	o.Sort = bson.D{{Key: "number", Value: 1}}
+
+	result := m.blocksCollection.FindOne(m.ctx, bson.M{}, o)
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	b := &models.Block{}
+	err := result.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetBlockByTimestamp returns the latest block with a timestamp at or before timestamp
+func (m *MongoDBProcessor) GetBlockByTimestamp(timestamp uint64) (*models.Block, error) {
+	o := &options.FindOneOptions{}
+	o.Sort = bson.D{{Key: "timestamp", Value: -1}}
+
+	result := m.blocksCollection.FindOne(m.ctx,
+		bson.M{"timestamp": bson.M{"$gt": 0, "$lte": timestamp}}, o)
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	b := &models.Block{}
+	err := result.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// VerifyChainLinkage checks that the prevHash of every stored block matches
+// the hash of the block before it. If not, it returns false along with the
+// number of the first mismatching block. Blocks indexed before prevHash was
+// stored are skipped.
+func (m *MongoDBProcessor) VerifyChainLinkage() (int64, bool, error) {
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "number", Value: 1}}
+	o.SetProjection(bson.M{"number": 1, "hash": 1, "prevHash": 1})
+
+	cursor, err := m.blocksCollection.Find(m.ctx, bson.M{}, o)
+	if err != nil {
+		return 0, false, err
+	}
+	defer cursor.Close(m.ctx)
+
+	var prevBlock *models.Block
+	for cursor.Next(m.ctx) {
+		b := &models.Block{}
+		err = cursor.Decode(b)
+		if err != nil {
+			return 0, false, err
+		}
+		if prevBlock != nil && prevBlock.Number == b.Number-1 &&
+			b.PrevHash != (common.Hash{}) && b.PrevHash != prevBlock.Hash {
+			return b.Number, false, nil
+		}
+		prevBlock = b
+	}
+
+	return 0, true, cursor.Err()
+}
+
+func (m *MongoDBProcessor) findBalanceChangeLog(filter bson.M, sort int) (*models.BalanceChangeLog, error) {
+	o := &options.FindOneOptions{}
+	o.Sort = bson.D{{Key: "blockNumber", Value: sort}}
+
+	result := m.balanceChangeLogsCollection.FindOne(m.ctx, filter, o)
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	b := &models.BalanceChangeLog{}
+	err := result.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GetBalanceAt returns the balance of address at the end of the block at
+// height. Heights before the retained blocks return ErrHistoryNotRetained.
+func (m *MongoDBProcessor) GetBalanceAt(address common.Address, height int64) (int64, error) {
+	firstBlock, err := m.GetFirstBlock()
+	if err != nil {
+		return 0, err
+	}
+	// The balance before the first retained block is known from its change logs
+	if height < firstBlock.Number-1 {
+		return 0, ErrHistoryNotRetained
+	}
+
+	b, err := m.findBalanceChangeLog(bson.M{"from": address, "blockNumber": bson.M{"$lte": height}}, -1)
+	if err == nil {
+		return b.BalanceAfter, nil
+	} else if err != ErrNotFound {
+		return 0, err
+	}
+
+	b, err = m.findBalanceChangeLog(bson.M{"from": address, "blockNumber": bson.M{"$gt": height}}, 1)
+	if err == nil {
+		return b.BalanceAfter - b.DeltaAmount, nil
+	} else if err != ErrNotFound {
+		return 0, err
+	}
+
+	a, err := m.GetAccountByAddress(address)
+	if err != nil {
+		return 0, err
+	}
+	return a.Balance, nil
+}
+
+// GetBalanceHistory returns the balance changes of address, latest first
+func (m *MongoDBProcessor) GetBalanceHistory(address common.Address, skip int64, limit int64) ([]*models.BalanceChangeLog, error) {
+	var balanceChangeLogs []*models.BalanceChangeLog
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "blockNumber", Value: -1}}
+	o.SetSkip(skip)
+	o.SetLimit(limit)
+
+	cursor, err := m.balanceChangeLogsCollection.Find(m.ctx, bson.M{"from": address}, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		b := &models.BalanceChangeLog{}
+		err = cursor.Decode(b)
+		if err != nil {
+			return nil, err
+		}
+		balanceChangeLogs = append(balanceChangeLogs, b)
+	}
+
+	return balanceChangeLogs, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetTokenByTxHash(txHash common.Hash) (*models.Token, error) {
+	result := m.tokensCollection.FindOne(m.ctx, bson.M{"txHash": txHash})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	t := &models.Token{}
+	err := result.Decode(t)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (m *MongoDBProcessor) GetTokensByBlockNumber(blockNumber int64) ([]*models.Token, error) {
+	var tokens []*models.Token
+
+	cursor, err := m.tokensCollection.Find(m.ctx, bson.M{"blockNumber": blockNumber})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		t := &models.Token{}
+		err = cursor.Decode(t)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetTokenHolder(tokenTxHash common.Hash, address common.Address) (*models.TokenHolder, error) {
+	result := m.tokenHoldersCollection.FindOne(m.ctx,
+		bson.M{"tokenTxHash": tokenTxHash, "address": address})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return models.NewTokenHolder(tokenTxHash, address), nil
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	t := &models.TokenHolder{}
+	err := result.Decode(t)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTokenHolders returns the holders of the token created by tokenTxHash,
+// ordered by token balance in descending order
+func (m *MongoDBProcessor) GetTokenHolders(tokenTxHash common.Hash, skip int64, limit int64) ([]*models.TokenHolder, error) {
+	var tokenHolders []*models.TokenHolder
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "balance", Value: -1}}
+	o.SetSkip(skip)
+	o.SetLimit(limit)
+
+	cursor, err := m.tokenHoldersCollection.Find(m.ctx,
+		bson.M{"tokenTxHash": tokenTxHash, "balance": bson.M{"$gt": 0}}, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		t := &models.TokenHolder{}
+		err = cursor.Decode(t)
+		if err != nil {
+			return nil, err
+		}
+		tokenHolders = append(tokenHolders, t)
+	}
+
+	return tokenHolders, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetTokenBalanceChangeLogsByBlockNumber(blockNumber int64) ([]*models.TokenBalanceChangeLog, error) {
+	var tokenBalanceChangeLogs []*models.TokenBalanceChangeLog
+
+	cursor, err := m.tokenBalanceChangeLogsCollection.Find(m.ctx,
+		bson.M{"blockNumber": blockNumber})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		t := &models.TokenBalanceChangeLog{}
+		err = cursor.Decode(t)
+		if err != nil {
+			return nil, err
+		}
+		tokenBalanceChangeLogs = append(tokenBalanceChangeLogs, t)
+	}
+
+	return tokenBalanceChangeLogs, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetSlaveByAddress(address common.Address) (*models.Slave, error) {
+	result := m.slavesCollection.FindOne(m.ctx, bson.M{"address": address})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	s := &models.Slave{}
+	err := result.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (m *MongoDBProcessor) GetSlavesByMasterAddress(masterAddress common.Address) ([]*models.Slave, error) {
+	var slaves []*models.Slave
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "blockNumber", Value: 1}}
+
+	cursor, err := m.slavesCollection.Find(m.ctx,
+		bson.M{"masterAddress": masterAddress}, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		s := &models.Slave{}
+		err = cursor.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		slaves = append(slaves, s)
+	}
+
+	return slaves, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetMultiSigAddress(address common.Address) (*models.MultiSigAddress, error) {
+	result := m.multiSigAddressesCollection.FindOne(m.ctx, bson.M{"address": address})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	a := &models.MultiSigAddress{}
+	err := result.Decode(a)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (m *MongoDBProcessor) GetMultiSigAddressesBySignatory(signatory common.Address) ([]*models.MultiSigAddress, error) {
+	var multiSigAddresses []*models.MultiSigAddress
+
+	cursor, err := m.multiSigAddressesCollection.Find(m.ctx,
+		bson.M{"signatories": signatory})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		a := &models.MultiSigAddress{}
+		err = cursor.Decode(a)
+		if err != nil {
+			return nil, err
+		}
+		multiSigAddresses = append(multiSigAddresses, a)
+	}
+
+	return multiSigAddresses, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetMultiSigAddressesByBlockNumber(blockNumber int64) ([]*models.MultiSigAddress, error) {
+	var multiSigAddresses []*models.MultiSigAddress
+
+	cursor, err := m.multiSigAddressesCollection.Find(m.ctx,
+		bson.M{"blockNumber": blockNumber})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		a := &models.MultiSigAddress{}
+		err = cursor.Decode(a)
+		if err != nil {
+			return nil, err
+		}
+		multiSigAddresses = append(multiSigAddresses, a)
+	}
+
+	return multiSigAddresses, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetMultiSigSpend(sharedKey common.Hash) (*models.MultiSigSpend, error) {
+	result := m.multiSigSpendsCollection.FindOne(m.ctx, bson.M{"sharedKey": sharedKey})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	s := &models.MultiSigSpend{}
+	err := result.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (m *MongoDBProcessor) GetMultiSigSpendsByExecutedBlockNumber(blockNumber int64) ([]*models.MultiSigSpend, error) {
+	var multiSigSpends []*models.MultiSigSpend
+
+	cursor, err := m.multiSigSpendsCollection.Find(m.ctx,
+		bson.M{"executed": true, "executedBlockNumber": blockNumber})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		s := &models.MultiSigSpend{}
+		err = cursor.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		multiSigSpends = append(multiSigSpends, s)
+	}
+
+	return multiSigSpends, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetMultiSigVotesByBlockNumber(blockNumber int64) ([]*models.MultiSigVote, error) {
+	var multiSigVotes []*models.MultiSigVote
+
+	cursor, err := m.multiSigVotesCollection.Find(m.ctx,
+		bson.M{"blockNumber": blockNumber})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		v := &models.MultiSigVote{}
+		err = cursor.Decode(v)
+		if err != nil {
+			return nil, err
+		}
+		multiSigVotes = append(multiSigVotes, v)
+	}
+
+	return multiSigVotes, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetMinerByAddress(address common.Address) (*models.Miner, error) {
+	result := m.minersCollection.FindOne(m.ctx, bson.M{"address": address})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return models.NewMiner(address), nil
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	miner := &models.Miner{}
+	err := result.Decode(miner)
+	if err != nil {
+		return nil, err
+	}
+	return miner, nil
+}
+
+// GetMiners returns the miners ordered by number of blocks mined in descending order
+func (m *MongoDBProcessor) GetMiners(skip int64, limit int64) ([]*models.Miner, error) {
+	var miners []*models.Miner
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "blocksMined", Value: -1}}
+	o.SetSkip(skip)
+	o.SetLimit(limit)
+
+	cursor, err := m.minersCollection.Find(m.ctx, bson.M{"blocksMined": bson.M{"$gt": 0}}, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		miner := &models.Miner{}
+		err = cursor.Decode(miner)
+		if err != nil {
+			return nil, err
+		}
+		miners = append(miners, miner)
+	}
+
+	return miners, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetMinedBlockByNumber(blockNumber int64) (*models.MinedBlock, error) {
+	result := m.minedBlocksCollection.FindOne(m.ctx, bson.M{"blockNumber": blockNumber})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	minedBlock := &models.MinedBlock{}
+	err := result.Decode(minedBlock)
+	if err != nil {
+		return nil, err
+	}
+	return minedBlock, nil
+}
+
+// GetMinerShares returns the share of blocks mined by each miner within the
+// last window blocks, ordered by share in descending order
+func (m *MongoDBProcessor) GetMinerShares(window int64) ([]*models.MinerShare, error) {
+	var minerShares []*models.MinerShare
+
+	b, err := m.GetLastBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"blockNumber": bson.M{"$gt": b.Number - window}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$miner", "blocksMined": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.M{"blocksMined": -1}}},
+	}
+	cursor, err := m.minedBlocksCollection.Aggregate(m.ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	totalBlocks := int64(0)
+	for cursor.Next(m.ctx) {
+		minerShare := &models.MinerShare{}
+		err = cursor.Decode(minerShare)
+		if err != nil {
+			return nil, err
+		}
+		totalBlocks += minerShare.BlocksMined
+		minerShares = append(minerShares, minerShare)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, minerShare := range minerShares {
+		minerShare.Share = float64(minerShare.BlocksMined) / float64(totalBlocks)
+	}
+
+	return minerShares, nil
+}
+
+// GetMinerSharesForConfiguredWindows returns the miner shares for each of the
+// windows configured in MinerShareWindows, keyed by window
+func (m *MongoDBProcessor) GetMinerSharesForConfiguredWindows() (map[int64][]*models.MinerShare, error) {
+	minerSharesByWindow := make(map[int64][]*models.MinerShare)
+	for _, window := range m.config.MinerShareWindows {
+		minerShares, err := m.GetMinerShares(window)
+		if err != nil {
+			return nil, err
+		}
+		minerSharesByWindow[window] = minerShares
+	}
+	return minerSharesByWindow, nil
+}
+
+func (m *MongoDBProcessor) GetProposal(sharedKey common.Hash) (*models.Proposal, error) {
+	result := m.proposalsCollection.FindOne(m.ctx, bson.M{"sharedKey": sharedKey})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	p := &models.Proposal{}
+	err := result.Decode(p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (m *MongoDBProcessor) getProposals(filter interface{}) ([]*models.Proposal, error) {
+	var proposals []*models.Proposal
+
+	cursor, err := m.proposalsCollection.Find(m.ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		p := &models.Proposal{}
+		err = cursor.Decode(p)
+		if err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, p)
+	}
+
+	return proposals, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetProposalsByExpiryBlockNumber(blockNumber int64) ([]*models.Proposal, error) {
+	return m.getProposals(bson.M{"expiryBlockNumber": blockNumber})
+}
+
+func (m *MongoDBProcessor) GetProposalsByFinalizedBlockNumber(blockNumber int64) ([]*models.Proposal, error) {
+	return m.getProposals(bson.M{"finalized": true, "finalizedBlockNumber": blockNumber})
+}
+
+// GetProposalVotes returns the votes for a proposal in the order they were included in the chain
+func (m *MongoDBProcessor) GetProposalVotes(sharedKey common.Hash) ([]*models.ProposalVote, error) {
+	var proposalVotes []*models.ProposalVote
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "blockNumber", Value: 1}, {Key: "_id", Value: 1}}
+
+	cursor, err := m.proposalVotesCollection.Find(m.ctx, bson.M{"sharedKey": sharedKey}, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		v := &models.ProposalVote{}
+		err = cursor.Decode(v)
+		if err != nil {
+			return nil, err
+		}
+		proposalVotes = append(proposalVotes, v)
+	}
+
+	return proposalVotes, cursor.Err()
+}
+
+// GetProposalTally returns the final tally of an expired proposal, otherwise
+// the live tally weighted by the current indexed balances of the voters
+func (m *MongoDBProcessor) GetProposalTally(sharedKey common.Hash) ([]*models.ProposalOptionTally, error) {
+	p, err := m.GetProposal(sharedKey)
+	if err != nil {
+		return nil, err
+	}
+	if p.Finalized {
+		return p.Tally, nil
+	}
+
+	proposalVotes, err := m.GetProposalVotes(sharedKey)
+	if err != nil {
+		return nil, err
+	}
+	return m.TallyProposal(p, proposalVotes, make(cache.AccountCache))
+}
+
+func (m *MongoDBProcessor) IsOTSKeyUsed(address common.Address, otsIndex uint64) (bool, error) {
+	count, err := m.otsKeyUsagesCollection.CountDocuments(m.ctx,
+		bson.M{"address": address, "otsIndex": otsIndex})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (m *MongoDBProcessor) GetOTSKeyUsagesByAddress(address common.Address) ([]*models.OTSKeyUsage, error) {
+	return m.getOTSKeyUsages(bson.M{"address": address})
+}
+
+func (m *MongoDBProcessor) GetOTSKeyUsagesByBlockNumber(blockNumber int64) ([]*models.OTSKeyUsage, error) {
+	return m.getOTSKeyUsages(bson.M{"blockNumber": blockNumber})
+}
+
+func (m *MongoDBProcessor) getOTSKeyUsages(filter interface{}) ([]*models.OTSKeyUsage, error) {
+	var otsKeyUsages []*models.OTSKeyUsage
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "otsIndex", Value: 1}}
+
+	cursor, err := m.otsKeyUsagesCollection.Find(m.ctx, filter, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		u := &models.OTSKeyUsage{}
+		err = cursor.Decode(u)
+		if err != nil {
+			return nil, err
+		}
+		otsKeyUsages = append(otsKeyUsages, u)
+	}
+
+	return otsKeyUsages, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetOTSKeyStatus(address common.Address) (*models.OTSKeyStatus, error) {
+	result := m.otsKeyStatusesCollection.FindOne(m.ctx, bson.M{"address": address})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	o := &models.OTSKeyStatus{}
+	err := result.Decode(o)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// GetAtRiskOTSKeyStatuses returns the addresses which have reused an OTS key
+// or are running out of OTS keys, ordered by remaining OTS keys
+func (m *MongoDBProcessor) GetAtRiskOTSKeyStatuses(skip int64, limit int64) ([]*models.OTSKeyStatus, error) {
+	var otsKeyStatuses []*models.OTSKeyStatus
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "remaining", Value: 1}}
+	o.SetSkip(skip)
+	o.SetLimit(limit)
+
+	cursor, err := m.otsKeyStatusesCollection.Find(m.ctx,
+		bson.M{"$or": bson.A{bson.M{"isReused": true}, bson.M{"isNearExhaustion": true}}}, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		s := &models.OTSKeyStatus{}
+		err = cursor.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		otsKeyStatuses = append(otsKeyStatuses, s)
+	}
+
+	return otsKeyStatuses, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetTransactionByHash(hash common.Hash) (*models.Transaction, error) {
+	result := m.transactionsCollection.FindOne(m.ctx, bson.M{"hash": hash})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	t := &models.Transaction{}
+	err := result.Decode(t)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTransactionsByAddress returns the transactions sent or received by address,
+// most recent first
+func (m *MongoDBProcessor) GetTransactionsByAddress(address common.Address, skip int64, limit int64) ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "blockNumber", Value: -1}}
+	o.SetSkip(skip)
+	o.SetLimit(limit)
+
+	cursor, err := m.transactionsCollection.Find(m.ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"from": address},
+			bson.M{"outputs.address": address},
+			bson.M{"multiSigAddress": address},
+		}}, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		t := &models.Transaction{}
+		err = cursor.Decode(t)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+
+	return transactions, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetRuleSet() (*models.RuleSet, error) {
+	result := m.ruleSetsCollection.FindOne(m.ctx, bson.M{})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	r := &models.RuleSet{}
+	err := result.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (m *MongoDBProcessor) GetRuleAuditsByAddress(address common.Address) ([]*models.RuleAudit, error) {
+	var ruleAudits []*models.RuleAudit
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "blockNumber", Value: 1}}
+
+	cursor, err := m.ruleAuditsCollection.Find(m.ctx, bson.M{"address": address}, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		r := &models.RuleAudit{}
+		err = cursor.Decode(r)
+		if err != nil {
+			return nil, err
+		}
+		ruleAudits = append(ruleAudits, r)
+	}
+
+	return ruleAudits, cursor.Err()
+}
+
+func (m *MongoDBProcessor) GetStatsByName(name string) (*models.Stats, error) {
+	result := m.statsCollection.FindOne(m.ctx, bson.M{"name": name})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return models.NewStats(name, 0), nil
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	s := &models.Stats{}
+	err := result.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (m *MongoDBProcessor) GetStats() (map[string]int64, error) {
+	stats := make(map[string]int64)
+
+	cursor, err := m.statsCollection.Find(m.ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		s := &models.Stats{}
+		err = cursor.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		stats[s.Name] = s.Value
+	}
+
+	return stats, cursor.Err()
+}
+
+// GetTopHoldersShare returns the share of the total supply held by the top
+// count holders, count must be one of the configured TopHolderCounts
+func (m *MongoDBProcessor) GetTopHoldersShare(count int64) (float64, error) {
+	totalSupply, err := m.GetStatsByName(models.StatsTotalSupply)
+	if err != nil {
+		return 0, err
+	}
+	if totalSupply.Value == 0 {
+		return 0, nil
+	}
+	topBalance, err := m.GetStatsByName(models.StatsTopBalance(count))
+	if err != nil {
+		return 0, err
+	}
+	return float64(topBalance.Value) / float64(totalSupply.Value), nil
+}
+
+func (m *MongoDBProcessor) GetRichListSnapshotByDay(day string) (*models.RichListSnapshot, error) {
+	result := m.richListSnapshotsCollection.FindOne(m.ctx, bson.M{"day": day})
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	s := &models.RichListSnapshot{}
+	err := result.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetRichListSnapshotByHeight returns the latest rich list snapshot taken at or before height
+func (m *MongoDBProcessor) GetRichListSnapshotByHeight(height int64) (*models.RichListSnapshot, error) {
+	o := &options.FindOneOptions{}
+	o.Sort = bson.D{{Key: "blockNumber", Value: -1}}
+
+	result := m.richListSnapshotsCollection.FindOne(m.ctx, bson.M{"blockNumber": bson.M{"$lte": height}}, o)
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	s := &models.RichListSnapshot{}
+	err := result.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetLatestDailyRichListSnapshot returns the latest snapshot taken at the
+// first block of a UTC day before blockNumber
+func (m *MongoDBProcessor) GetLatestDailyRichListSnapshot(blockNumber int64) (*models.RichListSnapshot, error) {
+	o := &options.FindOneOptions{}
+	o.Sort = bson.D{{Key: "blockNumber", Value: -1}}
+	o.SetProjection(bson.M{"holders": 0})
+
+	result := m.richListSnapshotsCollection.FindOne(m.ctx,
+		bson.M{"blockNumber": bson.M{"$lt": blockNumber}, "day": bson.M{"$exists": true}}, o)
+
+	if result.Err() == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	} else if result.Err() != nil {
+		return nil, result.Err()
+	}
+
+	s := &models.RichListSnapshot{}
+	err := result.Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// GetRichListSnapshots returns the block number, timestamp and day of the
+// snapshots taken from fromBlockNumber onwards, without their holders
+func (m *MongoDBProcessor) GetRichListSnapshots(fromBlockNumber int64, limit int64) ([]*models.RichListSnapshot, error) {
+	var richListSnapshots []*models.RichListSnapshot
+
+	o := &options.FindOptions{}
+	o.Sort = bson.D{{Key: "blockNumber", Value: 1}}
+	o.SetLimit(limit)
+	o.SetProjection(bson.M{"holders": 0})
+
+	cursor, err := m.richListSnapshotsCollection.Find(m.ctx, bson.M{"blockNumber": bson.M{"$gte": fromBlockNumber}}, o)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(m.ctx)
+
+	for cursor.Next(m.ctx) {
+		s := &models.RichListSnapshot{}
+		err = cursor.Decode(s)
+		if err != nil {
+			return nil, err
+		}
+		richListSnapshots = append(richListSnapshots, s)
+	}
+
+	return richListSnapshots, cursor.Err()
 }
@@ -0,0 +1,289 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+)
+
+func execJSON(q querier, query string, v interface{}, args ...interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec(query, append(args, string(data))...)
+	return err
+}
+
+// writeChanges stores the accounts and multisig spends updated by changes
+func writeChanges(tx *sql.Tx, changes *db.BlockChanges) error {
+	for addr, account := range changes.Accounts {
+		var hashFunction, treeHeight interface{}
+		if account.Descriptor != nil {
+			hashFunction = account.Descriptor.HashFunction
+			treeHeight = account.Descriptor.TreeHeight
+		}
+		err := execJSON(tx, `INSERT INTO accounts (address, balance, hash_function, tree_height, data)
+			VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (address) DO UPDATE SET balance = excluded.balance, data = excluded.data`,
+			account, addr, account.Balance, hashFunction, treeHeight)
+		if err != nil {
+			return err
+		}
+	}
+
+	for sharedKey, multiSigSpend := range changes.MultiSigSpends {
+		var executedBlockNumber interface{}
+		if multiSigSpend.Executed {
+			executedBlockNumber = multiSigSpend.ExecutedBlockNumber
+		}
+		err := execJSON(tx, `INSERT INTO multisig_spends (shared_key, block_number, executed_block_number, data)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (shared_key) DO UPDATE SET executed_block_number = excluded.executed_block_number,
+			data = excluded.data`,
+			multiSigSpend, sharedKey.ToString(), multiSigSpend.BlockNumber, executedBlockNumber)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ProcessBlock applies b in a transaction of its own
+func (p *SQLiteProcessor) ProcessBlock(b *generated.Block) error {
+	return p.ProcessBlocks([]*generated.Block{b})
+}
+
+// ProcessBlocks applies consecutive blocks in a single transaction
+func (p *SQLiteProcessor) ProcessBlocks(blocks []*generated.Block) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	firstBlock := blocks[0]
+	lastBlock := blocks[len(blocks)-1]
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		p.log.Error("[ProcessBlocks] failed to begin transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, b := range blocks {
+		err = p.processBlock(tx, b)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		p.log.Info("Failed to Process",
+			"From Block #", firstBlock.Header.BlockNumber,
+			"To Block #", lastBlock.Header.BlockNumber,
+			"Error", err)
+		return err
+	}
+
+	for _, b := range blocks {
+		p.log.Info("Processed",
+			"Block #", b.Header.BlockNumber,
+			"HeaderHash", hex.EncodeToString(b.Header.HashHeader))
+	}
+	return nil
+}
+
+// processBlock applies b within tx
+func (p *SQLiteProcessor) processBlock(tx *sql.Tx, b *generated.Block) error {
+	changes, err := db.ComputeBlockChanges(b, reader{tx}, p.rules)
+	if err != nil {
+		p.log.Error("[ProcessBlock] Failed to ComputeBlockChanges",
+			"Error", err.Error())
+		return err
+	}
+	blockNumber := changes.Block.Number
+
+	err = execJSON(tx, `INSERT INTO blocks (number, hash, data) VALUES (?, ?, ?)`,
+		changes.Block, blockNumber, changes.Block.Hash.ToString())
+	if err != nil {
+		p.log.Error("Failed to write in blocks",
+			"Error", err.Error())
+		return err
+	}
+
+	reOrgLimit := common.BLOCKZERO + p.config.ReOrgLimit
+	if !p.config.ArchiveMode && uint64(blockNumber) > reOrgLimit {
+		removeBlockNumber := int64(uint64(blockNumber) - reOrgLimit)
+		if _, err := tx.Exec(`DELETE FROM blocks WHERE number = ?`, removeBlockNumber); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM balance_change_logs WHERE block_number = ?`, removeBlockNumber); err != nil {
+			return err
+		}
+	}
+
+	err = writeChanges(tx, changes)
+	if err != nil {
+		p.log.Error("Failed to write in accounts",
+			"Error", err.Error())
+		return err
+	}
+
+	for addr, balanceChangeLog := range changes.BalanceChangeLogs {
+		err = execJSON(tx, `INSERT INTO balance_change_logs (block_number, address, data) VALUES (?, ?, ?)`,
+			balanceChangeLog, blockNumber, addr)
+		if err != nil {
+			p.log.Error("Failed to write in balance_change_logs",
+				"Error", err.Error())
+			return err
+		}
+	}
+
+	for _, multiSigAddress := range changes.MultiSigAddresses {
+		err = execJSON(tx, `INSERT INTO multisig_addresses (address, block_number, data) VALUES (?, ?, ?)`,
+			multiSigAddress, multiSigAddress.Address, blockNumber)
+		if err != nil {
+			p.log.Error("Failed to write in multisig_addresses",
+				"Error", err.Error())
+			return err
+		}
+	}
+
+	for _, multiSigVote := range changes.MultiSigVotes {
+		err = execJSON(tx, `INSERT INTO multisig_votes (block_number, shared_key, data) VALUES (?, ?, ?)`,
+			multiSigVote, blockNumber, multiSigVote.SharedKey.ToString())
+		if err != nil {
+			p.log.Error("Failed to write in multisig_votes",
+				"Error", err.Error())
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *SQLiteProcessor) RevertLastBlock() error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		p.log.Error("[RevertLastBlock] failed to begin transaction")
+		return err
+	}
+	defer tx.Rollback()
+
+	b, err := reader{tx}.GetLastBlock()
+	if err != nil {
+		p.log.Error("[RevertLastBlock] failed to get last block",
+			"error", err)
+		return err
+	}
+
+	changes, err := db.ComputeRevertChanges(b, reader{tx})
+	if err != nil {
+		p.log.Error("[RevertLastBlock] Failed to ComputeRevertChanges",
+			"Error", err.Error())
+		return err
+	}
+
+	err = writeChanges(tx, changes)
+	if err != nil {
+		p.log.Error("Failed to write in accounts",
+			"Error", err.Error())
+		return err
+	}
+
+	for _, statement := range []string{
+		`DELETE FROM blocks WHERE number = ?`,
+		`DELETE FROM balance_change_logs WHERE block_number = ?`,
+		`DELETE FROM multisig_addresses WHERE block_number = ?`,
+		`DELETE FROM multisig_spends WHERE block_number = ?`,
+		`DELETE FROM multisig_votes WHERE block_number = ?`,
+	} {
+		if _, err := tx.Exec(statement, b.Number); err != nil {
+			p.log.Error("[RevertLastBlock] Failed to delete reverted block data",
+				"Error", err.Error())
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		p.log.Info("Failed to Revert",
+			"Block #", b.Number,
+			"HeaderHash", b.Hash.ToString(),
+			"Error", err)
+		return err
+	}
+
+	p.log.Info("Reverted",
+		"Block #", b.Number,
+		"HeaderHash", b.Hash.ToString())
+	return nil
+}
+
+// ReconcileRules compares the loaded rules with the rule set the indexed data
+// was built with. If a rule changed, the blocks from the earliest changed
+// height onwards are reverted, so that they are reindexed with the new rules.
+func (p *SQLiteProcessor) ReconcileRules() error {
+	ruleSet, err := p.GetRuleSet()
+	if err == db.ErrNotFound {
+		return p.SaveRuleSet()
+	} else if err != nil {
+		return err
+	}
+	if ruleSet.Fingerprint == p.rules.Fingerprint() {
+		return nil
+	}
+
+	height, changed := p.rules.EarliestChangedHeight(ruleSet.Rules)
+	if changed {
+		b, err := p.GetLastBlock()
+		if err == db.ErrNotFound {
+			return p.SaveRuleSet()
+		} else if err != nil {
+			return err
+		}
+
+		if b.Number >= int64(height) {
+			if height == common.BLOCKZERO {
+				return fmt.Errorf("rules changed from genesis, delete %s and reindex",
+					p.config.GetSQLiteConfig().FilePath)
+			}
+			// Reverting stops at the block before height, which has to be retained
+			_, err := p.GetBlockByNumber(int64(height) - 1)
+			if err == db.ErrNotFound {
+				return fmt.Errorf("rules changed at height %d beyond ReOrgLimit, delete %s and reindex",
+					height, p.config.GetSQLiteConfig().FilePath)
+			} else if err != nil {
+				return err
+			}
+
+			p.log.Info("Rules changed, reverting blocks to reindex",
+				"from height", height,
+				"to height", b.Number)
+			for b.Number >= int64(height) {
+				err = p.RevertLastBlock()
+				if err != nil {
+					return err
+				}
+				b, err = p.GetLastBlock()
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return p.SaveRuleSet()
+}
+
+func (p *SQLiteProcessor) SaveRuleSet() error {
+	return execJSON(p.db, `INSERT INTO rule_sets (id, data) VALUES (1, ?)
+		ON CONFLICT (id) DO UPDATE SET data = excluded.data`, models.NewRuleSet(p.rules))
+}
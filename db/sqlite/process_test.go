@@ -0,0 +1,189 @@
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/theQRL/qrl-rich-list-indexer/config"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+	"github.com/theQRL/qrl-rich-list-indexer/log"
+	"github.com/theQRL/qrl-rich-list-indexer/misc"
+	"github.com/theQRL/qrl-rich-list-indexer/rules"
+)
+
+var minerAddress = []byte{0x01, 0x02, 0x03}
+
+func newTestProcessor(t *testing.T) *SQLiteProcessor {
+	sqlDB, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	p := &SQLiteProcessor{
+		db:     sqlDB,
+		config: config.GetConfig(),
+		log:    log.GetLogger(),
+	}
+	p.rules, _ = rules.NewEngine(nil)
+	if err := p.CreateTables(); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func newTestBlock(number uint64) *generated.Block {
+	return &generated.Block{
+		Header: &generated.BlockHeader{
+			BlockNumber:      number,
+			HashHeader:       []byte{byte(number), 0x01},
+			HashHeaderPrev:   []byte{byte(number - 1), 0x01},
+			TimestampSeconds: 1600000000 + number*60,
+		},
+		Transactions: []*generated.Transaction{{
+			TransactionHash: []byte{byte(number), 0x02},
+			TransactionType: &generated.Transaction_Coinbase{
+				Coinbase: &generated.Transaction_CoinBase{AddrTo: minerAddress, Amount: 100},
+			},
+		}},
+	}
+}
+
+func processTestBlocks(t *testing.T, p *SQLiteProcessor, from uint64, to uint64) {
+	var blocks []*generated.Block
+	for number := from; number <= to; number++ {
+		blocks = append(blocks, newTestBlock(number))
+	}
+	if err := p.ProcessBlocks(blocks); err != nil {
+		t.Fatalf("ProcessBlocks(%d, %d) error = %v", from, to, err)
+	}
+}
+
+func setTestRules(t *testing.T, p *SQLiteProcessor, r ...*rules.Rule) {
+	engine, err := rules.NewEngine(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.rules = engine
+}
+
+func TestReconcileRules(t *testing.T) {
+	p := newTestProcessor(t)
+	processTestBlocks(t, p, 0, 5)
+	if err := p.ReconcileRules(); err != nil {
+		t.Fatalf("ReconcileRules() error = %v", err)
+	}
+
+	setTestRules(t, p, &rules.Rule{
+		Address:     misc.ToStringAddress(minerAddress),
+		Action:      rules.ActionZero,
+		StartHeight: 3,
+	})
+	if err := p.ReconcileRules(); err != nil {
+		t.Fatalf("ReconcileRules() error = %v", err)
+	}
+	b, err := p.GetLastBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Number != 2 {
+		t.Fatalf("last block = %d after a rule change at height 3, want 2", b.Number)
+	}
+
+	processTestBlocks(t, p, 3, 5)
+	a, err := p.GetAccountByAddress(misc.ToStringAddress(minerAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Balance != 0 {
+		t.Errorf("Balance = %d after reindexing with a zero rule, want 0", a.Balance)
+	}
+
+	// The rule set is unchanged, nothing is reverted
+	if err := p.ReconcileRules(); err != nil {
+		t.Fatalf("ReconcileRules() error = %v", err)
+	}
+	if b, _ := p.GetLastBlock(); b.Number != 5 {
+		t.Errorf("last block = %d with unchanged rules, want 5", b.Number)
+	}
+}
+
+func TestReconcileRulesFromGenesis(t *testing.T) {
+	p := newTestProcessor(t)
+	processTestBlocks(t, p, 0, 2)
+	if err := p.ReconcileRules(); err != nil {
+		t.Fatalf("ReconcileRules() error = %v", err)
+	}
+
+	setTestRules(t, p, &rules.Rule{
+		Address: misc.ToStringAddress(minerAddress),
+		Action:  rules.ActionFreeze,
+	})
+	if err := p.ReconcileRules(); err == nil {
+		t.Error("ReconcileRules() of a rule changed from genesis returned no error")
+	}
+}
+
+func TestReconcileRulesExcludeOnly(t *testing.T) {
+	p := newTestProcessor(t)
+	processTestBlocks(t, p, 0, 2)
+	if err := p.ReconcileRules(); err != nil {
+		t.Fatalf("ReconcileRules() error = %v", err)
+	}
+
+	// Exclude rules change no balance, so that the new rule set is only saved
+	setTestRules(t, p, &rules.Rule{
+		Address: misc.ToStringAddress(minerAddress),
+		Action:  rules.ActionExclude,
+	})
+	if err := p.ReconcileRules(); err != nil {
+		t.Fatalf("ReconcileRules() error = %v", err)
+	}
+	if b, err := p.GetLastBlock(); err != nil || b.Number != 2 {
+		t.Errorf("GetLastBlock() = (%v, %v) after an exclude rule change, want block #2", b, err)
+	}
+	ruleSet, err := p.GetRuleSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ruleSet.Fingerprint != p.rules.Fingerprint() {
+		t.Error("rule set not saved after an exclude rule change")
+	}
+}
+
+func TestReconcileRulesBeyondReOrgLimit(t *testing.T) {
+	p := newTestProcessor(t)
+	p.config.ReOrgLimit = 3
+	processTestBlocks(t, p, 0, 9)
+	if err := p.ReconcileRules(); err != nil {
+		t.Fatalf("ReconcileRules() error = %v", err)
+	}
+
+	// Block #7 is the oldest block retained, so that a change at its height
+	// cannot be reverted without dropping every block
+	setTestRules(t, p, &rules.Rule{
+		Address:     misc.ToStringAddress(minerAddress),
+		Action:      rules.ActionZero,
+		StartHeight: 7,
+	})
+	if err := p.ReconcileRules(); err == nil {
+		t.Error("ReconcileRules() of a rule changed at the oldest retained block returned no error")
+	}
+	if b, err := p.GetLastBlock(); err != nil || b.Number != 9 {
+		t.Errorf("GetLastBlock() = (%v, %v) after a refused reconciliation, want block #9", b, err)
+	}
+
+	setTestRules(t, p, &rules.Rule{
+		Address:     misc.ToStringAddress(minerAddress),
+		Action:      rules.ActionZero,
+		StartHeight: 8,
+	})
+	if err := p.ReconcileRules(); err != nil {
+		t.Fatalf("ReconcileRules() error = %v", err)
+	}
+	if b, err := p.GetLastBlock(); err != nil || b.Number != 7 {
+		t.Errorf("GetLastBlock() = (%v, %v) after a rule change at height 8, want block #7", b, err)
+	}
+}
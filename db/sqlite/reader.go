@@ -0,0 +1,318 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strings"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx, so that the same reads
+// serve the API and the block being applied within its transaction
+type querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+type reader struct {
+	q querier
+}
+
+var _ db.BlockState = reader{}
+
+func (r reader) getOne(v interface{}, query string, args ...interface{}) error {
+	var data string
+	err := r.q.QueryRow(query, args...).Scan(&data)
+	if err == sql.ErrNoRows {
+		return db.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), v)
+}
+
+// getMany decodes the data column of every row into a new value created by newValue
+func (r reader) getMany(newValue func() interface{}, query string, args ...interface{}) error {
+	rows, err := r.q.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var data string
+		err = rows.Scan(&data)
+		if err != nil {
+			return err
+		}
+		err = json.Unmarshal([]byte(data), newValue())
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r reader) GetLastBlock() (*models.Block, error) {
+	b := &models.Block{}
+	err := r.getOne(b, `SELECT data FROM blocks ORDER BY number DESC LIMIT 1`)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r reader) GetBlockByNumber(number int64) (*models.Block, error) {
+	b := &models.Block{}
+	err := r.getOne(b, `SELECT data FROM blocks WHERE number = ?`, number)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r reader) GetFirstBlock() (*models.Block, error) {
+	b := &models.Block{}
+	err := r.getOne(b, `SELECT data FROM blocks ORDER BY number ASC LIMIT 1`)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (r reader) GetAccountByAddress(address common.Address) (*models.Account, error) {
+	a := &models.Account{}
+	err := r.getOne(a, `SELECT data FROM accounts WHERE address = ?`, address)
+	if err == db.ErrNotFound {
+		return models.NewAccount(address), nil
+	} else if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r reader) GetBalanceChangeLogsByBlockNumber(blockNumber int64) ([]*models.BalanceChangeLog, error) {
+	var balanceChangeLogs []*models.BalanceChangeLog
+	err := r.getMany(func() interface{} {
+		balanceChangeLog := &models.BalanceChangeLog{}
+		balanceChangeLogs = append(balanceChangeLogs, balanceChangeLog)
+		return balanceChangeLog
+	}, `SELECT data FROM balance_change_logs WHERE block_number = ?`, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return balanceChangeLogs, nil
+}
+
+func (r reader) GetMultiSigAddress(address common.Address) (*models.MultiSigAddress, error) {
+	a := &models.MultiSigAddress{}
+	err := r.getOne(a, `SELECT data FROM multisig_addresses WHERE address = ?`, address)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (r reader) GetMultiSigAddressesByBlockNumber(blockNumber int64) ([]*models.MultiSigAddress, error) {
+	var multiSigAddresses []*models.MultiSigAddress
+	err := r.getMany(func() interface{} {
+		a := &models.MultiSigAddress{}
+		multiSigAddresses = append(multiSigAddresses, a)
+		return a
+	}, `SELECT data FROM multisig_addresses WHERE block_number = ?`, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return multiSigAddresses, nil
+}
+
+func (r reader) GetMultiSigSpend(sharedKey common.Hash) (*models.MultiSigSpend, error) {
+	s := &models.MultiSigSpend{}
+	err := r.getOne(s, `SELECT data FROM multisig_spends WHERE shared_key = ?`, sharedKey.ToString())
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (r reader) GetMultiSigSpendsByExecutedBlockNumber(blockNumber int64) ([]*models.MultiSigSpend, error) {
+	var multiSigSpends []*models.MultiSigSpend
+	err := r.getMany(func() interface{} {
+		s := &models.MultiSigSpend{}
+		multiSigSpends = append(multiSigSpends, s)
+		return s
+	}, `SELECT data FROM multisig_spends WHERE executed_block_number = ?`, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return multiSigSpends, nil
+}
+
+func (r reader) GetMultiSigVotesByBlockNumber(blockNumber int64) ([]*models.MultiSigVote, error) {
+	var multiSigVotes []*models.MultiSigVote
+	err := r.getMany(func() interface{} {
+		v := &models.MultiSigVote{}
+		multiSigVotes = append(multiSigVotes, v)
+		return v
+	}, `SELECT data FROM multisig_votes WHERE block_number = ?`, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return multiSigVotes, nil
+}
+
+func (r reader) GetRuleSet() (*models.RuleSet, error) {
+	ruleSet := &models.RuleSet{}
+	err := r.getOne(ruleSet, `SELECT data FROM rule_sets WHERE id = 1`)
+	if err != nil {
+		return nil, err
+	}
+	return ruleSet, nil
+}
+
+func (p *SQLiteProcessor) GetLastBlock() (*models.Block, error) {
+	return reader{p.db}.GetLastBlock()
+}
+
+func (p *SQLiteProcessor) GetBlockByNumber(number int64) (*models.Block, error) {
+	return reader{p.db}.GetBlockByNumber(number)
+}
+
+func (p *SQLiteProcessor) GetAccountByAddress(address common.Address) (*models.Account, error) {
+	return reader{p.db}.GetAccountByAddress(address)
+}
+
+func (p *SQLiteProcessor) GetBalanceChangeLogsByBlockNumber(blockNumber int64) ([]*models.BalanceChangeLog, error) {
+	return reader{p.db}.GetBalanceChangeLogsByBlockNumber(blockNumber)
+}
+
+func (p *SQLiteProcessor) GetMultiSigAddress(address common.Address) (*models.MultiSigAddress, error) {
+	return reader{p.db}.GetMultiSigAddress(address)
+}
+
+func (p *SQLiteProcessor) GetMultiSigSpend(sharedKey common.Hash) (*models.MultiSigSpend, error) {
+	return reader{p.db}.GetMultiSigSpend(sharedKey)
+}
+
+func (p *SQLiteProcessor) GetRuleSet() (*models.RuleSet, error) {
+	return reader{p.db}.GetRuleSet()
+}
+
+// GetRichList returns the accounts with non zero balance ordered by balance in
+// descending order, restricted to the address type matching filter if not nil.
+// Addresses excluded by rules at the last indexed block are left out.
+func (p *SQLiteProcessor) GetRichList(filter *models.AddressTypeFilter, skip int64, limit int64) ([]*models.Account, error) {
+	var accounts []*models.Account
+
+	conditions := []string{"balance > 0"}
+	var args []interface{}
+
+	b, err := p.GetLastBlock()
+	if err != nil && err != db.ErrNotFound {
+		return nil, err
+	} else if err == nil {
+		excludedAddresses := p.rules.ExcludedAddresses(b.GetNumber())
+		if len(excludedAddresses) > 0 {
+			placeholders := make([]string, len(excludedAddresses))
+			for i, address := range excludedAddresses {
+				placeholders[i] = "?"
+				args = append(args, address)
+			}
+			conditions = append(conditions, "address NOT IN ("+strings.Join(placeholders, ", ")+")")
+		}
+	}
+
+	if filter != nil {
+		if filter.HashFunction != nil {
+			conditions = append(conditions, "hash_function = ?")
+			args = append(args, *filter.HashFunction)
+		}
+		if filter.TreeHeight != nil {
+			conditions = append(conditions, "tree_height = ?")
+			args = append(args, *filter.TreeHeight)
+		}
+	}
+
+	if limit <= 0 {
+		// SQLite treats a negative limit as no limit
+		limit = -1
+	}
+	args = append(args, limit, skip)
+
+	err = reader{p.db}.getMany(func() interface{} {
+		a := &models.Account{}
+		accounts = append(accounts, a)
+		return a
+	}, `SELECT data FROM accounts WHERE `+strings.Join(conditions, " AND ")+
+		` ORDER BY balance DESC LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func (p *SQLiteProcessor) GetFirstBlock() (*models.Block, error) {
+	return reader{p.db}.GetFirstBlock()
+}
+
+// GetBalanceAt returns the balance of address at the end of the block at
+// height. Heights before the retained blocks return db.ErrHistoryNotRetained.
+func (p *SQLiteProcessor) GetBalanceAt(address common.Address, height int64) (int64, error) {
+	r := reader{p.db}
+
+	firstBlock, err := r.GetFirstBlock()
+	if err != nil {
+		return 0, err
+	}
+	// The balance before the first retained block is known from its change logs
+	if height < firstBlock.Number-1 {
+		return 0, db.ErrHistoryNotRetained
+	}
+
+	b := &models.BalanceChangeLog{}
+	err = r.getOne(b, `SELECT data FROM balance_change_logs WHERE address = ? AND block_number <= ?
+		ORDER BY block_number DESC LIMIT 1`, address, height)
+	if err == nil {
+		return b.BalanceAfter, nil
+	} else if err != db.ErrNotFound {
+		return 0, err
+	}
+
+	err = r.getOne(b, `SELECT data FROM balance_change_logs WHERE address = ? AND block_number > ?
+		ORDER BY block_number ASC LIMIT 1`, address, height)
+	if err == nil {
+		return b.BalanceAfter - b.DeltaAmount, nil
+	} else if err != db.ErrNotFound {
+		return 0, err
+	}
+
+	a, err := r.GetAccountByAddress(address)
+	if err != nil {
+		return 0, err
+	}
+	return a.Balance, nil
+}
+
+// GetBalanceHistory returns the balance changes of address, latest first
+func (p *SQLiteProcessor) GetBalanceHistory(address common.Address, skip int64, limit int64) ([]*models.BalanceChangeLog, error) {
+	var balanceChangeLogs []*models.BalanceChangeLog
+
+	if limit <= 0 {
+		limit = -1
+	}
+	err := reader{p.db}.getMany(func() interface{} {
+		b := &models.BalanceChangeLog{}
+		balanceChangeLogs = append(balanceChangeLogs, b)
+		return b
+	}, `SELECT data FROM balance_change_logs WHERE address = ? ORDER BY block_number DESC LIMIT ? OFFSET ?`,
+		address, limit, skip)
+	if err != nil {
+		return nil, err
+	}
+	return balanceChangeLogs, nil
+}
@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"database/sql"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/theQRL/qrl-rich-list-indexer/config"
+	"github.com/theQRL/qrl-rich-list-indexer/db"
+	"github.com/theQRL/qrl-rich-list-indexer/log"
+	"github.com/theQRL/qrl-rich-list-indexer/rules"
+)
+
+// SQLiteProcessor stores the accounts, blocks and balanceChangeLogs in a
+// single SQLite file. Every model is kept as JSON in the data column, the
+// other columns are only there to be queried and indexed.
+type SQLiteProcessor struct {
+	db *sql.DB
+
+	config *config.Config
+	log    log.LoggerInterface
+
+	rules *rules.Engine
+}
+
+var _ db.Storage = (*SQLiteProcessor)(nil)
+
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS blocks (
+		number INTEGER PRIMARY KEY,
+		hash TEXT NOT NULL,
+		data TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS accounts (
+		address TEXT PRIMARY KEY,
+		balance INTEGER NOT NULL,
+		hash_function INTEGER,
+		tree_height INTEGER,
+		data TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS accounts_balance ON accounts (balance DESC)`,
+	`CREATE TABLE IF NOT EXISTS balance_change_logs (
+		block_number INTEGER NOT NULL,
+		address TEXT NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (block_number, address)
+	)`,
+	`CREATE INDEX IF NOT EXISTS balance_change_logs_address ON balance_change_logs (address, block_number DESC)`,
+	`CREATE TABLE IF NOT EXISTS multisig_addresses (
+		address TEXT PRIMARY KEY,
+		block_number INTEGER NOT NULL,
+		data TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS multisig_addresses_block_number ON multisig_addresses (block_number)`,
+	`CREATE TABLE IF NOT EXISTS multisig_spends (
+		shared_key TEXT PRIMARY KEY,
+		block_number INTEGER NOT NULL,
+		executed_block_number INTEGER,
+		data TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS multisig_spends_block_number ON multisig_spends (block_number)`,
+	`CREATE INDEX IF NOT EXISTS multisig_spends_executed_block_number ON multisig_spends (executed_block_number)`,
+	`CREATE TABLE IF NOT EXISTS multisig_votes (
+		block_number INTEGER NOT NULL,
+		shared_key TEXT NOT NULL,
+		data TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS multisig_votes_block_number ON multisig_votes (block_number)`,
+	// Single row holding the rule set the indexed data was built with
+	`CREATE TABLE IF NOT EXISTS rule_sets (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		data TEXT NOT NULL
+	)`,
+}
+
+func (p *SQLiteProcessor) CreateTables() error {
+	for _, statement := range schema {
+		if _, err := p.db.Exec(statement); err != nil {
+			p.log.Error("Error while creating sqlite tables",
+				"Error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *SQLiteProcessor) Close() error {
+	return p.db.Close()
+}
+
+func CreateSQLiteProcessor() (*SQLiteProcessor, error) {
+	p := &SQLiteProcessor{}
+	p.log = log.GetLogger()
+	p.config = config.GetConfig()
+
+	filePath := p.config.GetSQLiteConfig().FilePath
+	sqlDB, err := sql.Open("sqlite3", filePath+"?_foreign_keys=on&_journal_mode=WAL")
+	if err != nil {
+		p.log.Error("Failed to open sqlite database",
+			"FilePath", filePath,
+			"Error", err)
+		return nil, err
+	}
+	// A single connection serializes the block transactions, as the
+	// indexer is the only writer of the file
+	sqlDB.SetMaxOpenConns(1)
+	p.db = sqlDB
+
+	err = p.CreateTables()
+	if err != nil {
+		return nil, err
+	}
+
+	p.rules, err = rules.LoadEngine(p.config.RulesFilePath)
+	if err != nil {
+		p.log.Error("Failed to load rules",
+			"RulesFilePath", p.config.RulesFilePath,
+			"Error", err)
+		return nil, err
+	}
+
+	return p, nil
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+	"github.com/theQRL/qrl-rich-list-indexer/db/models"
+	"github.com/theQRL/qrl-rich-list-indexer/generated"
+)
+
+// ErrNotFound is returned by Storage reads when the requested block does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrHistoryNotRetained is returned for balances older than the retained
+// change logs, as they are only kept beyond ReOrgLimit in ArchiveMode
+var ErrHistoryNotRetained = errors.New("balance history not retained, enable ArchiveMode")
+
+// ErrMigrationPending is returned by reads depending on data backfilled by a
+// migration which has not been applied yet
+var ErrMigrationPending = errors.New("migration pending, run the migrate command")
+
+// Storage is implemented by every backend the indexer can keep its state in.
+// ProcessBlock and RevertLastBlock must apply the whole block or nothing.
+// ProcessBlocks applies consecutive blocks, and must leave the storage at the
+// end of one of them if it fails.
+type Storage interface {
+	ProcessBlock(b *generated.Block) error
+	ProcessBlocks(blocks []*generated.Block) error
+	RevertLastBlock() error
+
+	GetLastBlock() (*models.Block, error)
+	GetBlockByNumber(number int64) (*models.Block, error)
+	GetAccountByAddress(address common.Address) (*models.Account, error)
+	GetBalanceChangeLogsByBlockNumber(blockNumber int64) ([]*models.BalanceChangeLog, error)
+	GetRichList(filter *models.AddressTypeFilter, skip int64, limit int64) ([]*models.Account, error)
+
+	GetBalanceAt(address common.Address, height int64) (int64, error)
+	GetBalanceHistory(address common.Address, skip int64, limit int64) ([]*models.BalanceChangeLog, error)
+}
+
+var _ Storage = (*MongoDBProcessor)(nil)
@@ -3,6 +3,7 @@ module github.com/theQRL/qrl-rich-list-indexer
 go 1.20
 
 require (
+	github.com/mattn/go-sqlite3 v1.14.17
 	go.mongodb.org/mongo-driver v1.11.7
 	golang.org/x/crypto v0.10.0
 	google.golang.org/genproto v0.0.0-20230306155012-7f2fa6fef1f4
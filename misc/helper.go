@@ -2,6 +2,8 @@ package misc
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 
 	"github.com/theQRL/qrl-rich-list-indexer/common"
 	"golang.org/x/crypto/sha3"
@@ -40,3 +42,13 @@ func SHA256(out, msg []byte) []byte {
 	copy(out, hashOut)
 	return out
 }
+
+func ToByteAddress(address common.Address) (common.ByteAddress, error) {
+	var sizedAddress common.ByteAddress
+	str := address.ToString()
+	if len(str) != 2*len(sizedAddress)+1 || str[0] != 'Q' {
+		return sizedAddress, fmt.Errorf("invalid address %s", str)
+	}
+	_, err := hex.Decode(sizedAddress[:], []byte(str[1:]))
+	return sizedAddress, err
+}
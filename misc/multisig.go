@@ -0,0 +1,25 @@
+package misc
+
+import (
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+)
+
+var multiSigAddressDescriptor = []byte{0x11, 0x00, 0x00}
+
+// GetMultiSigAddress derives the address of the multi sig wallet created
+// by the MultiSigCreate transaction with the given transaction hash
+func GetMultiSigAddress(txHash []byte) common.Address {
+	var address common.ByteAddress
+	descSize := len(multiSigAddressDescriptor)
+	copy(address[:descSize], multiSigAddressDescriptor)
+
+	var prevHash [32]byte
+	SHA256(prevHash[:], append(append([]byte{}, multiSigAddressDescriptor...), txHash...))
+	copy(address[descSize:], prevHash[:])
+
+	var newHash [32]byte
+	SHA256(newHash[:], address[:descSize+len(prevHash)])
+	copy(address[descSize+len(prevHash):], newHash[len(newHash)-4:])
+
+	return address.ToAddress()
+}
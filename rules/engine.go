@@ -0,0 +1,144 @@
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+)
+
+type ruleFile struct {
+	Rules []*Rule `json:"rules"`
+}
+
+type Engine struct {
+	rules     []*Rule
+	byAddress map[common.Address][]*Rule
+}
+
+func NewEngine(rules []*Rule) (*Engine, error) {
+	e := &Engine{
+		byAddress: make(map[common.Address][]*Rule),
+	}
+	for _, r := range rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+		e.rules = append(e.rules, r)
+		e.byAddress[r.Address] = append(e.byAddress[r.Address], r)
+	}
+	return e, nil
+}
+
+// LoadEngine reads the rules from the JSON file at path. An empty path means
+// indexing without rules, while a missing file is an error, as it would
+// silently drop every rule the indexed data was built with.
+func LoadEngine(path string) (*Engine, error) {
+	if path == "" {
+		return NewEngine(nil)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &ruleFile{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %s: %v", path, err)
+	}
+	return NewEngine(f.Rules)
+}
+
+func (e *Engine) Rules() []*Rule {
+	return e.rules
+}
+
+// ActiveRule returns the first rule for address with the given action active at height
+func (e *Engine) ActiveRule(address common.Address, action Action, height uint64) *Rule {
+	for _, r := range e.byAddress[address] {
+		if r.Action == action && r.IsActive(height) {
+			return r
+		}
+	}
+	return nil
+}
+
+// ActiveRules returns the rules with the given action active at height
+func (e *Engine) ActiveRules(action Action, height uint64) []*Rule {
+	var rules []*Rule
+	for _, r := range e.rules {
+		if r.Action == action && r.IsActive(height) {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func (e *Engine) ExcludedAddresses(height uint64) []common.Address {
+	var addresses []common.Address
+	for _, r := range e.ActiveRules(ActionExclude, height) {
+		addresses = append(addresses, r.Address)
+	}
+	return addresses
+}
+
+func sortedRuleStrings(rules []*Rule) []string {
+	ruleStrings := make([]string, len(rules))
+	for i, r := range rules {
+		ruleStrings[i] = r.String()
+	}
+	sort.Strings(ruleStrings)
+	return ruleStrings
+}
+
+// Fingerprint identifies the rule set independent of the order of the rules
+func (e *Engine) Fingerprint() string {
+	h := sha256.New()
+	for _, s := range sortedRuleStrings(e.rules) {
+		h.Write([]byte(s))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// EarliestChangedHeight compares the rules with previousRules and returns the
+// lowest start height among the rules added, removed or modified, and false
+// if no balance is affected by the change. Exclude rules are left out, as
+// they change no balance and take effect without reindexing.
+func (e *Engine) EarliestChangedHeight(previousRules []*Rule) (uint64, bool) {
+	current := make(map[string]*Rule)
+	for _, r := range e.rules {
+		if r.Action != ActionExclude {
+			current[r.String()] = r
+		}
+	}
+	previous := make(map[string]*Rule)
+	for _, r := range previousRules {
+		if r.Action != ActionExclude {
+			previous[r.String()] = r
+		}
+	}
+
+	changed := false
+	var height uint64
+	update := func(r *Rule) {
+		if !changed || r.StartHeight < height {
+			height = r.StartHeight
+		}
+		changed = true
+	}
+	for key, r := range current {
+		if _, ok := previous[key]; !ok {
+			update(r)
+		}
+	}
+	for key, r := range previous {
+		if _, ok := current[key]; !ok {
+			update(r)
+		}
+	}
+	return height, changed
+}
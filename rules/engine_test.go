@@ -0,0 +1,233 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+)
+
+func newRule(address string, action Action, startHeight uint64, endHeight uint64) *Rule {
+	return &Rule{
+		Address:     common.Address("Q" + address),
+		Action:      action,
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+		Reason:      "test",
+	}
+}
+
+func TestRuleIsActive(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   *Rule
+		height uint64
+		want   bool
+	}{
+		{name: "before start", rule: newRule("a", ActionZero, 100, 200), height: 99},
+		{name: "at start", rule: newRule("a", ActionZero, 100, 200), height: 100, want: true},
+		{name: "at end", rule: newRule("a", ActionZero, 100, 200), height: 200, want: true},
+		{name: "after end", rule: newRule("a", ActionZero, 100, 200), height: 201},
+		{name: "open ended before start", rule: newRule("a", ActionZero, 100, 0), height: 99},
+		{name: "open ended at start", rule: newRule("a", ActionZero, 100, 0), height: 100, want: true},
+		{name: "open ended far after start", rule: newRule("a", ActionZero, 100, 0), height: 1 << 40, want: true},
+		{name: "single height", rule: newRule("a", ActionZero, 100, 100), height: 100, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.IsActive(tt.height); got != tt.want {
+				t.Errorf("IsActive(%d) = %v, want %v", tt.height, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngineEarliestChangedHeight(t *testing.T) {
+	tests := []struct {
+		name          string
+		previousRules []*Rule
+		rules         []*Rule
+		wantHeight    uint64
+		wantChanged   bool
+		sameRules     bool
+	}{
+		{
+			name: "unchanged",
+			previousRules: []*Rule{
+				newRule("a", ActionZero, 100, 0),
+				newRule("b", ActionExclude, 50, 80),
+			},
+			rules: []*Rule{
+				newRule("b", ActionExclude, 50, 80),
+				newRule("a", ActionZero, 100, 0),
+			},
+			sameRules: true,
+		},
+		{
+			name:      "both empty",
+			sameRules: true,
+		},
+		{
+			name:          "rule added",
+			previousRules: []*Rule{newRule("a", ActionZero, 100, 0)},
+			rules: []*Rule{
+				newRule("a", ActionZero, 100, 0),
+				newRule("b", ActionFreeze, 300, 0),
+			},
+			wantHeight:  300,
+			wantChanged: true,
+		},
+		{
+			name:          "first rule added",
+			previousRules: nil,
+			rules:         []*Rule{newRule("a", ActionZero, 100, 0)},
+			wantHeight:    100,
+			wantChanged:   true,
+		},
+		{
+			name: "rule removed",
+			previousRules: []*Rule{
+				newRule("a", ActionZero, 100, 0),
+				newRule("b", ActionFreeze, 30, 40),
+			},
+			rules:       []*Rule{newRule("a", ActionZero, 100, 0)},
+			wantHeight:  30,
+			wantChanged: true,
+		},
+		{
+			name:          "start height moved later",
+			previousRules: []*Rule{newRule("a", ActionZero, 100, 0)},
+			rules:         []*Rule{newRule("a", ActionZero, 150, 0)},
+			wantHeight:    100,
+			wantChanged:   true,
+		},
+		{
+			name:          "start height moved earlier",
+			previousRules: []*Rule{newRule("a", ActionZero, 100, 0)},
+			rules:         []*Rule{newRule("a", ActionZero, 60, 0)},
+			wantHeight:    60,
+			wantChanged:   true,
+		},
+		{
+			name:          "open ended rule closed",
+			previousRules: []*Rule{newRule("a", ActionZero, 100, 0)},
+			rules:         []*Rule{newRule("a", ActionZero, 100, 500)},
+			wantHeight:    100,
+			wantChanged:   true,
+		},
+		{
+			name:          "action edited",
+			previousRules: []*Rule{newRule("a", ActionExclude, 70, 0)},
+			rules:         []*Rule{newRule("a", ActionFreeze, 70, 0)},
+			wantHeight:    70,
+			wantChanged:   true,
+		},
+		{
+			name:          "rule added from genesis",
+			previousRules: []*Rule{newRule("a", ActionZero, 100, 0)},
+			rules: []*Rule{
+				newRule("a", ActionZero, 100, 0),
+				newRule("b", ActionFreeze, 0, 0),
+			},
+			wantHeight:  0,
+			wantChanged: true,
+		},
+		{
+			name:          "exclude rule added",
+			previousRules: []*Rule{newRule("a", ActionZero, 100, 0)},
+			rules: []*Rule{
+				newRule("a", ActionZero, 100, 0),
+				newRule("b", ActionExclude, 0, 0),
+			},
+		},
+		{
+			name: "exclude rule removed",
+			previousRules: []*Rule{
+				newRule("a", ActionZero, 100, 0),
+				newRule("b", ActionExclude, 50, 80),
+			},
+			rules: []*Rule{newRule("a", ActionZero, 100, 0)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewEngine(tt.rules)
+			if err != nil {
+				t.Fatalf("NewEngine() error = %v", err)
+			}
+			height, changed := e.EarliestChangedHeight(tt.previousRules)
+			if height != tt.wantHeight || changed != tt.wantChanged {
+				t.Errorf("EarliestChangedHeight() = (%d, %v), want (%d, %v)",
+					height, changed, tt.wantHeight, tt.wantChanged)
+			}
+
+			previous, err := NewEngine(tt.previousRules)
+			if err != nil {
+				t.Fatalf("NewEngine() error = %v", err)
+			}
+			if sameFingerprint := e.Fingerprint() == previous.Fingerprint(); sameFingerprint != tt.sameRules {
+				t.Errorf("same Fingerprint() = %v, want %v", sameFingerprint, tt.sameRules)
+			}
+		})
+	}
+}
+
+func TestEngineFingerprintIgnoresOrder(t *testing.T) {
+	a := newRule("a", ActionZero, 100, 0)
+	b := newRule("b", ActionExclude, 50, 80)
+
+	e1, err := NewEngine([]*Rule{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e2, err := NewEngine([]*Rule{b, a})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e1.Fingerprint() != e2.Fingerprint() {
+		t.Error("Fingerprint() depends on the order of the rules")
+	}
+}
+
+func TestLoadEngineMissingFile(t *testing.T) {
+	if _, err := LoadEngine(filepath.Join(t.TempDir(), "rules.json")); err == nil {
+		t.Error("LoadEngine() of a missing file returned no error")
+	}
+
+	e, err := LoadEngine("")
+	if err != nil {
+		t.Fatalf("LoadEngine(\"\") error = %v", err)
+	}
+	if len(e.Rules()) != 0 {
+		t.Errorf("len(Rules()) = %d without a rules file, want 0", len(e.Rules()))
+	}
+}
+
+func TestLoadEngine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	data := `{"rules": [{"address": "Qa", "action": "exclude", "startHeight": 10, "endHeight": 0, "reason": "test"}]}`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	e, err := LoadEngine(path)
+	if err != nil {
+		t.Fatalf("LoadEngine() error = %v", err)
+	}
+	if r := e.ActiveRule("Qa", ActionExclude, 10); r == nil {
+		t.Error("ActiveRule() = nil at start height")
+	}
+	if r := e.ActiveRule("Qa", ActionExclude, 9); r != nil {
+		t.Error("ActiveRule() active before start height")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"rules": [`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadEngine(path); err == nil {
+		t.Error("LoadEngine() of an invalid file returned no error")
+	}
+}
@@ -0,0 +1,55 @@
+package rules
+
+import (
+	"fmt"
+
+	"github.com/theQRL/qrl-rich-list-indexer/common"
+)
+
+type Action string
+
+const (
+	// ActionFreeze skips as a whole every transfer sending from or to the address while the
+	// rule is active, while fees and mining rewards still apply
+	ActionFreeze Action = "freeze"
+	// ActionExclude keeps indexing the address, but leaves it out of the rich list. Rich list
+	// snapshots already taken keep the exclusions active when they were taken.
+	ActionExclude Action = "exclude"
+	// ActionZero sets the balance of the address to zero at every block while the rule is active
+	ActionZero Action = "zero"
+)
+
+type Rule struct {
+	Address     common.Address `json:"address" bson:"address"`
+	Action      Action         `json:"action" bson:"action"`
+	StartHeight uint64         `json:"startHeight" bson:"startHeight"`
+	EndHeight   uint64         `json:"endHeight" bson:"endHeight"` // Inclusive, 0 means the rule never ends
+	Reason      string         `json:"reason" bson:"reason"`
+}
+
+func (r *Rule) IsActive(height uint64) bool {
+	if height < r.StartHeight {
+		return false
+	}
+	return r.EndHeight == 0 || height <= r.EndHeight
+}
+
+func (r *Rule) Validate() error {
+	switch r.Action {
+	case ActionFreeze, ActionExclude, ActionZero:
+	default:
+		return fmt.Errorf("invalid action %s for address %s", r.Action, r.Address)
+	}
+	if len(r.Address) == 0 {
+		return fmt.Errorf("missing address in rule")
+	}
+	if r.EndHeight != 0 && r.EndHeight < r.StartHeight {
+		return fmt.Errorf("end height %d before start height %d for address %s",
+			r.EndHeight, r.StartHeight, r.Address)
+	}
+	return nil
+}
+
+func (r *Rule) String() string {
+	return fmt.Sprintf("%s|%s|%d|%d|%s", r.Address, r.Action, r.StartHeight, r.EndHeight, r.Reason)
+}